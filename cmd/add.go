@@ -0,0 +1,267 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/longkey1/gcal/internal/gcal"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/calendar/v3"
+)
+
+var (
+	addTitle       string
+	addStart       string
+	addEnd         string
+	addDuration    time.Duration
+	addAllDay      bool
+	addLocation    string
+	addDescription string
+	addAttendees   []string
+	addCalendar    string
+	addOutput      string
+	addFromFile    string
+	addFileFormat  string
+	addDryRun      bool
+)
+
+var addCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Create a calendar event",
+	Long: `Create an event on one of the configured calendars.
+The event is created on the first calendar in calendar_id_list unless --calendar is given.
+With --from-file, the events of a CSV, JSON or NDJSON file are created instead. Each row
+or object has the fields title, start, end, duration, all_day, location, description,
+attendees and calendar, which work like the flags of the same name; CSV files need a
+header row. Every row is validated before any event is created.
+Creating events requires write access; run 'gcal auth --write' first when using OAuth.`,
+	Example: `  # Create a one hour meeting
+  gcal add --title "Team Meeting" --start "2024-01-15 10:00"
+
+  # Create a meeting with an explicit end time
+  gcal add --title "Workshop" --start "2024-01-15 13:00" --end "2024-01-15 17:00"
+
+  # Create a 30 minute meeting with attendees
+  gcal add --title "1on1" --start "2024-01-15 15:00" --duration 30m --attendee alice@example.com
+
+  # Create a three day all-day event
+  gcal add --title "Conference" --start 2024-01-15 --end 2024-01-17 --all-day
+
+  # Create an event on a specific calendar
+  gcal add --title "Release" --start 2024-01-20 --calendar team@group.calendar.google.com
+
+  # Create the events listed in a CSV file, checking them first
+  gcal add --from-file onboarding.csv --dry-run
+  gcal add --from-file onboarding.csv
+
+  # Create events from NDJSON on stdin
+  generate-rotation | gcal add --from-file - --format ndjson`,
+	Args:    cobra.NoArgs,
+	PreRunE: validateAddFlags,
+	RunE:    runAdd,
+}
+
+func validateAddFlags(cmd *cobra.Command, args []string) error {
+	if addFromFile != "" {
+		for _, name := range []string{"title", "start", "end", "duration", "all-day", "location", "description", "attendee"} {
+			if cmd.Flags().Lookup(name).Changed {
+				return fmt.Errorf("cannot use --%s with --from-file", name)
+			}
+		}
+		if addFileFormat != "" && !slices.Contains(addFileFormats, addFileFormat) {
+			return fmt.Errorf("invalid format: %s (valid: %s)", addFileFormat, strings.Join(addFileFormats, ", "))
+		}
+		return validateOutputFormat(addOutput, resultOutputFormats)
+	}
+	if cmd.Flags().Lookup("format").Changed {
+		return fmt.Errorf("--format can only be used with --from-file")
+	}
+
+	if addTitle == "" {
+		return fmt.Errorf("--title is required")
+	}
+
+	if addStart == "" {
+		return fmt.Errorf("--start is required")
+	}
+
+	// Validate that --end and --duration are not used together
+	if cmd.Flags().Lookup("end").Changed && cmd.Flags().Lookup("duration").Changed {
+		return fmt.Errorf("cannot use --end and --duration together")
+	}
+
+	if addDuration < 0 {
+		return fmt.Errorf("--duration must be positive")
+	}
+
+	return validateOutputFormat(addOutput, resultOutputFormats)
+}
+
+func runAdd(cmd *cobra.Command, args []string) error {
+	if addFromFile != "" {
+		return runAddFromFile()
+	}
+
+	event, err := buildAddEvent()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	// The calendar is resolved from the config, so that a dry run needs no write access
+	cid, err := (&gcal.Service{CalendarIDList: cfg.CalendarIDList}).ResolveCalendarID(addCalendar)
+	if err != nil {
+		return err
+	}
+
+	if addDryRun {
+		opts := newOutputOptions(map[*calendar.Event]string{event: cid})
+		opts.columns, _ = parseColumns([]string{"date", "start", "end", "title", "calendar"})
+		fmt.Println("Would create:")
+		return outputTable(os.Stdout, []*calendar.Event{event}, opts)
+	}
+
+	ctx := context.Background()
+	svc, err := gcal.NewWriteService(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("unable to create gcal service: %w", err)
+	}
+
+	created, err := svc.Calendar.Events.Insert(cid, event).Do()
+	if err != nil {
+		return fmt.Errorf("unable to create event: %w", wrapWriteError(err))
+	}
+
+	if err := outputEvents(os.Stdout, []*calendar.Event{created}, addOutput, newOutputOptions(map[*calendar.Event]string{created: cid})); err != nil {
+		return fmt.Errorf("unable to output event: %w", err)
+	}
+
+	return nil
+}
+
+// addInput holds the fields of an event to create, given as flags or as a row of an input file
+type addInput struct {
+	Title       string
+	Start       string
+	End         string
+	Duration    time.Duration
+	AllDay      bool
+	Location    string
+	Description string
+	Attendees   []string
+	Calendar    string
+}
+
+// buildAddEvent builds the event to insert from the add flags
+func buildAddEvent() (*calendar.Event, error) {
+	in := addInput{
+		Title:       addTitle,
+		Start:       addStart,
+		End:         addEnd,
+		Duration:    addDuration,
+		AllDay:      addAllDay,
+		Location:    addLocation,
+		Description: addDescription,
+		Attendees:   addAttendees,
+	}
+	return in.build()
+}
+
+// build validates the input and builds the event to insert
+func (in addInput) build() (*calendar.Event, error) {
+	if in.Title == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+	if in.Start == "" {
+		return nil, fmt.Errorf("start is required")
+	}
+	if in.End != "" && in.Duration != 0 {
+		return nil, fmt.Errorf("cannot use end and duration together")
+	}
+	if in.Duration < 0 {
+		return nil, fmt.Errorf("duration must be positive")
+	}
+
+	start, dateOnly, err := parseEventTime(in.Start)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start: %w", err)
+	}
+	allDay := in.AllDay || dateOnly
+
+	var end time.Time
+	switch {
+	case in.End != "":
+		end, _, err = parseEventTime(in.End)
+		if err != nil {
+			return nil, fmt.Errorf("invalid end: %w", err)
+		}
+		if allDay {
+			// All-day end dates are exclusive in the API, the given end is inclusive
+			end = end.AddDate(0, 0, 1)
+		}
+	case allDay:
+		if in.Duration > 0 {
+			return nil, fmt.Errorf("duration cannot be used with all-day events, use end instead")
+		}
+		end = start.AddDate(0, 0, 1)
+	default:
+		duration := in.Duration
+		if duration == 0 {
+			duration = time.Hour
+		}
+		end = start.Add(duration)
+	}
+
+	if !end.After(start) {
+		return nil, fmt.Errorf("end must be after start")
+	}
+
+	return &calendar.Event{
+		Summary:     in.Title,
+		Location:    in.Location,
+		Description: in.Description,
+		Start:       newEventDateTime(start, allDay),
+		End:         newEventDateTime(end, allDay),
+		Attendees:   newAttendees(in.Attendees),
+	}, nil
+}
+
+func init() {
+	rootCmd.AddCommand(addCmd)
+	addCmd.Flags().StringVar(&addTitle, "title", "", "Event title (required)")
+	addCmd.Flags().StringVarP(&addStart, "start", "s", "", "Start time (YYYY-MM-DD HH:MM, or YYYY-MM-DD for all-day events)")
+	addCmd.Flags().StringVarP(&addEnd, "end", "e", "", "End time (inclusive date for all-day events)")
+	addCmd.Flags().DurationVar(&addDuration, "duration", 0, "Event duration, e.g. 30m, 1h30m (default 1h)")
+	addCmd.Flags().BoolVar(&addAllDay, "all-day", false, "Create an all-day event")
+	addCmd.Flags().StringVar(&addLocation, "location", "", "Event location")
+	addCmd.Flags().StringVar(&addDescription, "description", "", "Event description")
+	addCmd.Flags().StringSliceVar(&addAttendees, "attendee", []string{}, "Attendee email address (can be repeated)")
+	addCmd.Flags().StringVar(&addCalendar, "calendar", "", "Calendar ID to create the event on (default: first in calendar_id_list)")
+	addCmd.Flags().StringVarP(&addOutput, "output", "o", "table", "Output format: "+strings.Join(resultOutputFormats, ", "))
+	addCmd.Flags().StringVar(&addFromFile, "from-file", "", "Create the events of a CSV, JSON or NDJSON file (- for stdin)")
+	addCmd.Flags().StringVar(&addFileFormat, "format", "", "Format of --from-file: csv, json, ndjson (default from the file extension or content)")
+	addCmd.Flags().BoolVar(&addDryRun, "dry-run", false, "Validate and show the events without creating them")
+}
@@ -0,0 +1,399 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/longkey1/gcal/internal/gcal"
+	"google.golang.org/api/calendar/v3"
+)
+
+// addFileFormats are the formats accepted by --from-file
+var addFileFormats = []string{"csv", "json", "ndjson"}
+
+// addRecord is an event as written in an input file
+type addRecord struct {
+	Title       string     `json:"title"`
+	Start       string     `json:"start"`
+	End         string     `json:"end"`
+	Duration    string     `json:"duration"`
+	AllDay      bool       `json:"all_day"`
+	Location    string     `json:"location"`
+	Description string     `json:"description"`
+	Attendees   stringList `json:"attendees"`
+	Calendar    string     `json:"calendar"`
+}
+
+// stringList is a JSON array of strings that may also be given as a single string
+type stringList []string
+
+func (l *stringList) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*l = splitList(s)
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(b, &list); err != nil {
+		return fmt.Errorf("attendees must be a string or an array of strings")
+	}
+	*l = list
+	return nil
+}
+
+// splitList splits a list separated by commas, semicolons or whitespace
+func splitList(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ';' || r == ' ' || r == '\t' || r == '\n'
+	})
+}
+
+func (r addRecord) input() (addInput, error) {
+	in := addInput{
+		Title:       r.Title,
+		Start:       r.Start,
+		End:         r.End,
+		AllDay:      r.AllDay,
+		Location:    r.Location,
+		Description: r.Description,
+		Attendees:   r.Attendees,
+		Calendar:    r.Calendar,
+	}
+	if r.Duration != "" {
+		d, err := time.ParseDuration(r.Duration)
+		if err != nil {
+			return in, fmt.Errorf("invalid duration: %s", r.Duration)
+		}
+		in.Duration = d
+	}
+	return in, nil
+}
+
+// addRow is a record read from an input file together with the line it starts at
+type addRow struct {
+	line   int
+	record addRecord
+	// err is set when the row could not be read
+	err error
+}
+
+func runAddFromFile() error {
+	rows, err := readAddFile(addFromFile, addFileFormat)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return fmt.Errorf("no events found in %s", addFromFile)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	// Validate every row before creating anything
+	calendars := &gcal.Service{CalendarIDList: cfg.CalendarIDList}
+	reqs := make([]gcal.InsertRequest, 0, len(rows))
+	var problems []string
+	for _, row := range rows {
+		req, err := row.request(calendars)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("line %d: %v", row.line, err))
+			continue
+		}
+		reqs = append(reqs, req)
+	}
+	if len(problems) > 0 {
+		for _, p := range problems {
+			fmt.Fprintln(os.Stderr, p)
+		}
+		return fmt.Errorf("%d of %d rows are invalid, no events were created", len(problems), len(rows))
+	}
+
+	opts := newOutputOptions(make(map[*calendar.Event]string))
+	opts.columns, _ = parseColumns([]string{"date", "start", "end", "title", "calendar"})
+
+	if addDryRun {
+		events := make([]*calendar.Event, len(reqs))
+		for i, req := range reqs {
+			events[i] = req.Event
+			opts.calendars[req.Event] = req.CalendarID
+		}
+		if err := outputTable(os.Stdout, events, opts); err != nil {
+			return err
+		}
+		fmt.Printf("\n%d events would be created.\n", len(events))
+		return nil
+	}
+
+	ctx := context.Background()
+	svc, err := gcal.NewWriteService(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("unable to create gcal service: %w", err)
+	}
+
+	created := make([]*calendar.Event, 0, len(reqs))
+	failed := 0
+	for i, result := range svc.InsertEvents(ctx, reqs) {
+		if result.Err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "line %d: unable to create event: %v\n", rows[i].line, wrapWriteError(result.Err))
+			continue
+		}
+		created = append(created, result.Event)
+		opts.calendars[result.Event] = reqs[i].CalendarID
+	}
+
+	opts.columns, _ = parseColumns([]string{"id", "date", "start", "end", "title", "calendar"})
+	if err := outputEvents(os.Stdout, created, addOutput, opts); err != nil {
+		return fmt.Errorf("unable to output events: %w", err)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d events could not be created", failed, len(reqs))
+	}
+	return nil
+}
+
+// request validates the row and builds the insert request for it
+func (row addRow) request(calendars *gcal.Service) (gcal.InsertRequest, error) {
+	if row.err != nil {
+		return gcal.InsertRequest{}, row.err
+	}
+	in, err := row.record.input()
+	if err != nil {
+		return gcal.InsertRequest{}, err
+	}
+	event, err := in.build()
+	if err != nil {
+		return gcal.InsertRequest{}, err
+	}
+	calendarID := in.Calendar
+	if calendarID == "" {
+		calendarID = addCalendar
+	}
+	cid, err := calendars.ResolveCalendarID(calendarID)
+	if err != nil {
+		return gcal.InsertRequest{}, err
+	}
+	return gcal.InsertRequest{CalendarID: cid, Event: event}, nil
+}
+
+// readAddFile reads the rows of an input file, or of stdin if path is -.
+// Without a format it is taken from the file extension or, failing that, from the content.
+func readAddFile(path, format string) ([]addRow, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s: %w", path, err)
+	}
+
+	if format == "" {
+		format = detectAddFileFormat(path, data)
+	}
+	switch format {
+	case "csv":
+		return readAddCSV(data)
+	case "json":
+		return readAddJSON(data)
+	default:
+		return readAddNDJSON(data)
+	}
+}
+
+func detectAddFileFormat(path string, data []byte) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return "csv"
+	case ".json":
+		return "json"
+	case ".ndjson", ".jsonl":
+		return "ndjson"
+	}
+	switch trimmed := bytes.TrimLeft(data, " \t\r\n\ufeff"); {
+	case bytes.HasPrefix(trimmed, []byte("[")):
+		return "json"
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		return "ndjson"
+	default:
+		return "csv"
+	}
+}
+
+// addCSVColumns maps the accepted CSV header names to the record field they set
+var addCSVColumns = map[string]func(r *addRecord, v string) error{
+	"title":       func(r *addRecord, v string) error { r.Title = v; return nil },
+	"start":       func(r *addRecord, v string) error { r.Start = v; return nil },
+	"end":         func(r *addRecord, v string) error { r.End = v; return nil },
+	"duration":    func(r *addRecord, v string) error { r.Duration = v; return nil },
+	"location":    func(r *addRecord, v string) error { r.Location = v; return nil },
+	"description": func(r *addRecord, v string) error { r.Description = v; return nil },
+	"attendees":   func(r *addRecord, v string) error { r.Attendees = splitList(v); return nil },
+	"calendar":    func(r *addRecord, v string) error { r.Calendar = v; return nil },
+	"all_day": func(r *addRecord, v string) error {
+		if v == "" {
+			return nil
+		}
+		switch strings.ToLower(v) {
+		case "yes", "y":
+			r.AllDay = true
+			return nil
+		case "no", "n":
+			r.AllDay = false
+			return nil
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid all_day: %s", v)
+		}
+		r.AllDay = b
+		return nil
+	},
+}
+
+// addCSVAliases are alternative header names, e.g. those of the csv output format
+var addCSVAliases = map[string]string{
+	"summary":     "title",
+	"calendar_id": "calendar",
+	"all-day":     "all_day",
+	"allday":      "all_day",
+	"attendee":    "attendees",
+}
+
+func readAddCSV(data []byte) ([]addRow, error) {
+	cr := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff"))))
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	setters := make([]func(r *addRecord, v string) error, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if alias, ok := addCSVAliases[name]; ok {
+			name = alias
+		}
+		setter, ok := addCSVColumns[name]
+		if !ok {
+			// Columns such as event_id or organizer of the csv output format are ignored
+			continue
+		}
+		setters[i] = setter
+	}
+
+	var rows []addRow
+	for {
+		fields, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+		row := addRow{line: line}
+		if len(fields) > len(header) {
+			row.err = fmt.Errorf("%d fields, but the header has %d", len(fields), len(header))
+		}
+		for i, v := range fields {
+			if row.err != nil || i >= len(setters) || setters[i] == nil {
+				continue
+			}
+			row.err = setters[i](&row.record, strings.TrimSpace(v))
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func readAddJSON(data []byte) ([]addRow, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return nil, fmt.Errorf("invalid JSON: expected an array of events")
+	}
+
+	var rows []addRow
+	for dec.More() {
+		offset := dec.InputOffset()
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, fmt.Errorf("invalid JSON at line %d: %w", lineAt(data, offset), err)
+		}
+		row := addRow{line: lineAt(data, offset)}
+		row.err = decodeAddRecord(raw, &row.record)
+		rows = append(rows, row)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	return rows, nil
+}
+
+func readAddNDJSON(data []byte) ([]addRow, error) {
+	var rows []addRow
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if i == 0 {
+			line = bytes.TrimPrefix(line, []byte("\ufeff"))
+		}
+		if len(line) == 0 {
+			continue
+		}
+		row := addRow{line: i + 1}
+		row.err = decodeAddRecord(line, &row.record)
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// decodeAddRecord decodes a JSON object, rejecting unknown fields
+func decodeAddRecord(b []byte, r *addRecord) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(r); err != nil {
+		return fmt.Errorf("invalid event: %w", err)
+	}
+	return nil
+}
+
+// lineAt returns the line of the first value at or after offset, skipping separators
+func lineAt(data []byte, offset int64) int {
+	i := int(offset)
+	for i < len(data) && strings.IndexByte(" \t\r\n,", data[i]) >= 0 {
+		i++
+	}
+	return bytes.Count(data[:i], []byte("\n")) + 1
+}
@@ -16,28 +16,66 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/longkey1/gcal/internal/gcal"
 	"github.com/longkey1/gcal/internal/google"
 	"github.com/spf13/cobra"
 )
 
+var (
+	authWrite     bool
+	authManage    bool
+	authNoBrowser bool
+	authDevice    bool
+	authTimeout   time.Duration
+)
+
 // authCmd represents the auth command
 var authCmd = &cobra.Command{
 	Use:   "auth",
 	Short: "Authenticate with Google Calendar API using OAuth",
 	Long: `Authenticate with Google Calendar API using OAuth.
 This command initiates the OAuth flow to obtain and save access tokens.
-Only applicable when auth_type is set to "oauth" in config.`,
+Only applicable when auth_type is set to "oauth" in config.
+By default only read access is requested; use --write to allow commands
+such as "gcal add" to create and modify events, and --manage to also allow
+"gcal calendars" to create, delete and subscribe to calendars.
+On machines without a browser, use --no-browser to open the URL elsewhere and
+paste the result back. --device runs the device authorization grant instead,
+but Google does not grant Calendar scopes with it, so it fails for Google
+accounts and is only useful with other OAuth providers.`,
 	Example: `  # Authenticate with Google Calendar
   gcal auth
 
+  # Authenticate with permission to create and modify events
+  gcal auth --write
+
+  # Authenticate with permission to manage calendars
+  gcal auth --manage
+
+  # Authenticate on a machine without a browser, e.g. over SSH
+  gcal auth --no-browser
+
   # Re-authenticate (will prompt for confirmation)
   gcal auth`,
-	Args: cobra.NoArgs,
-	RunE: runAuth,
+	Args:    cobra.NoArgs,
+	PreRunE: validateAuthFlags,
+	RunE:    runAuth,
+}
+
+func validateAuthFlags(cmd *cobra.Command, args []string) error {
+	if authNoBrowser && authDevice {
+		return fmt.Errorf("cannot use --no-browser and --device together")
+	}
+	if authTimeout <= 0 {
+		return fmt.Errorf("--timeout must be positive")
+	}
+	return nil
 }
 
 func runAuth(cmd *cobra.Command, args []string) error {
@@ -50,25 +88,52 @@ func runAuth(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("auth command is only available for OAuth authentication (current: %s)", cfg.AuthType)
 	}
 
-	// Check if token already exists
-	if _, err := os.Stat(cfg.GoogleUserCredentials); err == nil {
-		fmt.Printf("Token file already exists: %s\n", cfg.GoogleUserCredentials)
-		fmt.Print("Do you want to re-authenticate? [y/N]: ")
-		var response string
-		fmt.Scanln(&response)
-		if response != "y" && response != "Y" {
+	store, err := cfg.NewTokenStore()
+	if err != nil {
+		return err
+	}
+
+	// Check if token already exists. A token that cannot be read, e.g. for a missing
+	// passphrase or a locked keyring, is reported rather than silently replaced.
+	_, err = store.Load()
+	if err != nil && !errors.Is(err, google.ErrTokenNotFound) {
+		return fmt.Errorf("unable to read the existing token from %s: %w", store, err)
+	}
+	if err == nil {
+		fmt.Printf("Token already exists: %s\n", store)
+		if !confirm("Do you want to re-authenticate?") {
 			fmt.Println("Cancelled.")
 			return nil
 		}
 	}
 
+	scopes := google.ReadOnlyScopes
+	switch {
+	case authManage:
+		scopes = google.ManageScopes
+	case authWrite:
+		scopes = google.ReadWriteScopes
+	}
+
 	// Run OAuth flow
 	auth := google.NewOAuthAuthenticator(
 		cfg.GoogleApplicationCredentials,
-		cfg.GoogleUserCredentials,
+		store,
+		scopes...,
 	)
 
-	if err := auth.Authenticate(); err != nil {
+	ctx, cancel := context.WithTimeout(context.Background(), authTimeout)
+	defer cancel()
+
+	switch {
+	case authNoBrowser:
+		err = auth.AuthenticateManual(os.Stdin)
+	case authDevice:
+		err = auth.AuthenticateDevice(ctx)
+	default:
+		err = auth.Authenticate(ctx)
+	}
+	if err != nil {
 		return fmt.Errorf("authentication failed: %w", err)
 	}
 
@@ -78,4 +143,9 @@ func runAuth(cmd *cobra.Command, args []string) error {
 
 func init() {
 	rootCmd.AddCommand(authCmd)
+	authCmd.Flags().BoolVar(&authWrite, "write", false, "Request permission to create and modify events")
+	authCmd.Flags().BoolVar(&authManage, "manage", false, "Request permission to manage calendars, which includes --write")
+	authCmd.Flags().BoolVar(&authNoBrowser, "no-browser", false, "Print the URL to open elsewhere and read the redirect URL or code from stdin")
+	authCmd.Flags().BoolVar(&authDevice, "device", false, "Use the device authorization flow with a code entered on another device")
+	authCmd.Flags().DurationVar(&authTimeout, "timeout", 5*time.Minute, "How long to wait for access to be granted in the browser or on the other device")
 }
@@ -0,0 +1,128 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/longkey1/gcal/internal/gcal"
+	"github.com/longkey1/gcal/internal/google"
+	"github.com/spf13/cobra"
+)
+
+var (
+	authMigrateFrom string
+	authMigrateTo   string
+	authMigrateKeep bool
+)
+
+var authMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Move the saved token to another token store",
+	Long: `Move the OAuth token from one token store to another, for example from the
+plain user_credentials file to the OS keyring. The token is copied to the new
+store and then deleted from the old one unless --keep is given.
+--from defaults to the token_store of the config file. Set token_store to the
+new store afterwards so that gcal uses it.
+The encrypted store reads its passphrase from the GCAL_TOKEN_PASSPHRASE
+environment variable.`,
+	Example: `  # Move the token file into the OS keyring
+  gcal auth migrate --to keyring
+
+  # Encrypt the token file
+  GCAL_TOKEN_PASSPHRASE=... gcal auth migrate --from file --to encrypted`,
+	Args:    cobra.NoArgs,
+	PreRunE: validateAuthMigrateFlags,
+	RunE:    runAuthMigrate,
+}
+
+func validateAuthMigrateFlags(cmd *cobra.Command, args []string) error {
+	for _, kind := range []string{authMigrateFrom, authMigrateTo} {
+		if kind != "" && !slices.Contains(google.TokenStoreKinds, kind) {
+			return fmt.Errorf("invalid token store: %s (valid: %s)", kind, strings.Join(google.TokenStoreKinds, ", "))
+		}
+	}
+	return nil
+}
+
+func runAuthMigrate(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	if cfg.AuthType != gcal.AuthTypeOAuth {
+		return fmt.Errorf("auth migrate is only available for OAuth authentication (current: %s)", cfg.AuthType)
+	}
+
+	from := authMigrateFrom
+	if from == "" {
+		from = cfg.TokenStore
+	}
+	if from == authMigrateTo {
+		return fmt.Errorf("the token is already in the %s store", from)
+	}
+	// The file and encrypted stores both use the user_credentials path
+	sharedPath := from != google.TokenStoreKeyring && authMigrateTo != google.TokenStoreKeyring
+	if authMigrateKeep && sharedPath {
+		return fmt.Errorf("--keep cannot be used between the file and encrypted stores, which both use %s", cfg.GoogleUserCredentials)
+	}
+
+	src, err := cfg.NewTokenStoreOf(from)
+	if err != nil {
+		return err
+	}
+	dst, err := cfg.NewTokenStoreOf(authMigrateTo)
+	if err != nil {
+		return err
+	}
+
+	token, err := src.Load()
+	if err != nil {
+		return fmt.Errorf("unable to read token: %w", err)
+	}
+
+	// The token is saved before it is deleted from the old store, so that it is never lost.
+	// Between the file-based stores, saving atomically replaces the old file instead.
+	if err := dst.Save(token); err != nil {
+		return fmt.Errorf("unable to save token to %s: %w", dst, err)
+	}
+	if !sharedPath && !authMigrateKeep {
+		if err := src.Delete(); err != nil {
+			return fmt.Errorf("token copied to %s, but unable to delete it from %s: %w", dst, src, err)
+		}
+	}
+
+	if authMigrateKeep {
+		fmt.Printf("Token copied from %s to %s.\n", src, dst)
+	} else {
+		fmt.Printf("Token moved from %s to %s.\n", src, dst)
+	}
+	if cfg.TokenStore != authMigrateTo {
+		fmt.Printf("Set token_store = %q in the config file to use it.\n", authMigrateTo)
+	}
+	return nil
+}
+
+func init() {
+	authCmd.AddCommand(authMigrateCmd)
+	authMigrateCmd.Flags().StringVar(&authMigrateFrom, "from", "", "Token store to move the token from: file, keyring, encrypted (default from token_store config)")
+	authMigrateCmd.Flags().StringVar(&authMigrateTo, "to", "", "Token store to move the token to: file, keyring, encrypted")
+	authMigrateCmd.Flags().BoolVar(&authMigrateKeep, "keep", false, "Keep the token in the old store as well")
+	authMigrateCmd.MarkFlagRequired("to")
+}
@@ -0,0 +1,80 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/longkey1/gcal/internal/gcal"
+	"github.com/longkey1/gcal/internal/google"
+	"github.com/spf13/cobra"
+)
+
+var authRevokeYes bool
+
+var authRevokeCmd = &cobra.Command{
+	Use:   "revoke",
+	Short: "Revoke the saved token and sign out",
+	Long: `Revoke the OAuth token at Google, so that it can no longer be used, and delete
+it from the token store. Run "gcal auth" to sign in again.`,
+	Example: `  # Sign out (asks for confirmation)
+  gcal auth revoke
+
+  # Sign out without confirmation
+  gcal auth revoke --yes`,
+	Args: cobra.NoArgs,
+	RunE: runAuthRevoke,
+}
+
+func runAuthRevoke(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	if cfg.AuthType != gcal.AuthTypeOAuth {
+		return fmt.Errorf("auth revoke is only available for OAuth authentication (current: %s)", cfg.AuthType)
+	}
+	store, err := cfg.NewTokenStore()
+	if err != nil {
+		return err
+	}
+	if _, err := store.Load(); err != nil {
+		return fmt.Errorf("no token to revoke: %w", err)
+	}
+
+	if !authRevokeYes {
+		fmt.Printf("Token: %s\n", store)
+		if !confirm("Revoke the token and delete it?") {
+			fmt.Println("Cancelled.")
+			return nil
+		}
+	}
+
+	auth := google.NewOAuthAuthenticator(cfg.GoogleApplicationCredentials, store)
+	if err := auth.Revoke(context.Background()); err != nil {
+		return err
+	}
+
+	fmt.Println("Token revoked.")
+	return nil
+}
+
+func init() {
+	authCmd.AddCommand(authRevokeCmd)
+	authRevokeCmd.Flags().BoolVarP(&authRevokeYes, "yes", "y", false, "Revoke without confirmation")
+}
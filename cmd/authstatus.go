@@ -0,0 +1,136 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/longkey1/gcal/internal/gcal"
+	"github.com/longkey1/gcal/internal/google"
+	"github.com/spf13/cobra"
+	"golang.org/x/oauth2"
+)
+
+// exitInvalidToken is the exit status of gcal auth status when the credentials are not usable
+const exitInvalidToken = 1
+
+var authStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the state of the saved credentials",
+	Long: `Show the authentication type, the credential files, and the account, scopes
+and expiry of the token. An expired access token is refreshed first.
+The exit status is 1 when the credentials are missing, invalid or revoked.`,
+	Example: `  # Check which account gcal uses
+  gcal auth status`,
+	Args: cobra.NoArgs,
+	RunE: runAuthStatus,
+}
+
+func runAuthStatus(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Auth type:\t%s\n", cfg.AuthType)
+
+	var token *oauth2.Token
+	var account string
+	if cfg.AuthType == gcal.AuthTypeServiceAccount {
+		fmt.Fprintf(tw, "Service account file:\t%s\n", describePath(cfg.GoogleApplicationCredentials))
+		auth := google.NewServiceAccountAuthenticator(cfg.GoogleApplicationCredentials)
+		account, _ = auth.Email()
+		token, err = auth.Token(ctx, google.ReadOnlyScopes...)
+	} else {
+		fmt.Fprintf(tw, "Client secret file:\t%s\n", describePath(cfg.GoogleApplicationCredentials))
+		var store google.TokenStore
+		store, err = cfg.NewTokenStore()
+		if err == nil {
+			if s, ok := store.(*google.FileTokenStore); ok {
+				fmt.Fprintf(tw, "Token file:\t%s\n", describePath(s.Path()))
+			} else {
+				fmt.Fprintf(tw, "Token store:\t%s\n", store)
+			}
+			token, err = google.NewOAuthAuthenticator(cfg.GoogleApplicationCredentials, store).Token(ctx)
+		}
+	}
+
+	var info *google.TokenInfo
+	if err == nil {
+		info, err = google.FetchTokenInfo(ctx, token.AccessToken)
+	}
+	if err != nil {
+		fmt.Fprintf(tw, "Status:\tinvalid (%v)\n", err)
+		tw.Flush()
+		cmd.SilenceErrors = true
+		return &exitError{code: exitInvalidToken, err: err}
+	}
+
+	if info.Email != "" {
+		account = info.Email
+	}
+	if account == "" {
+		// Without the email scope, the ID of the primary calendar is the address of the account
+		if svc, err := gcal.NewService(ctx, cfg); err == nil {
+			if c, err := svc.Calendar.Calendars.Get("primary").Context(ctx).Do(); err == nil {
+				account = c.Id
+			}
+		}
+	}
+	writeTokenStatus(tw, token, info, account)
+	return tw.Flush()
+}
+
+func writeTokenStatus(w io.Writer, token *oauth2.Token, info *google.TokenInfo, account string) {
+	if account != "" {
+		fmt.Fprintf(w, "Account:\t%s\n", account)
+	}
+	fmt.Fprintf(w, "Scopes:\t%s\n", strings.Join(info.Scopes, " "))
+	expiry := info.Expiry
+	if expiry.IsZero() {
+		expiry = token.Expiry
+	}
+	if !expiry.IsZero() {
+		fmt.Fprintf(w, "Access token expires:\t%s (in %s)\n", expiry.Local().Format("2006-01-02 15:04:05"), formatCountdown(time.Until(expiry)))
+	}
+	if token.RefreshToken != "" {
+		fmt.Fprintf(w, "Refresh token:\tpresent\n")
+	}
+	fmt.Fprintf(w, "Status:\tvalid\n")
+}
+
+// describePath returns path, noting when the file does not exist
+func describePath(path string) string {
+	if path == "" {
+		return "(not configured)"
+	}
+	if _, err := os.Stat(path); err != nil {
+		return path + " (not found)"
+	}
+	return path
+}
+
+func init() {
+	authCmd.AddCommand(authStatusCmd)
+}
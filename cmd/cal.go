@@ -0,0 +1,347 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/longkey1/gcal/internal/gcal"
+	"github.com/spf13/cobra"
+)
+
+var (
+	calThree           bool
+	calYear            bool
+	calMark            string
+	calColor           string
+	calWeekStart       string
+	calIncludeDeclined bool
+	calPartial         bool
+)
+
+// calMonthWidth is the width of a rendered month: 7 cells of 4 characters
+const calMonthWidth = 7 * 4
+
+var calCmd = &cobra.Command{
+	Use:   "cal [[month] year]",
+	Short: "Show a month calendar with busy days",
+	Long: `Show a cal(1)-style month grid marking the days that have events
+on the configured calendars.
+With --mark count, the number of events is shown next to the day
+("+" for more than nine); with --mark shade, busy days are shaded darker
+the more events they have. Today is highlighted.
+As with cal(1), a single argument is a year and two arguments are a month and a year.`,
+	Example: `  # Show the current month
+  gcal cal
+
+  # Show the previous, current and next month
+  gcal cal -3
+
+  # Show the whole year
+  gcal cal --year
+  gcal cal 2024
+
+  # Show a specific month with busy shading
+  gcal cal 3 2024 --mark shade`,
+	Args:    cobra.MaximumNArgs(2),
+	PreRunE: validateCalFlags,
+	RunE:    runCal,
+}
+
+func validateCalFlags(cmd *cobra.Command, args []string) error {
+	if calThree && calYear {
+		return fmt.Errorf("cannot use -3 and --year together")
+	}
+
+	validMarks := map[string]bool{"count": true, "shade": true}
+	if !validMarks[calMark] {
+		return fmt.Errorf("invalid mark option: %s (valid: count, shade)", calMark)
+	}
+
+	validColors := map[string]bool{"auto": true, "always": true, "never": true}
+	if !validColors[calColor] {
+		return fmt.Errorf("invalid color option: %s (valid: auto, always, never)", calColor)
+	}
+
+	return nil
+}
+
+func runCal(cmd *cobra.Command, args []string) error {
+	now := time.Now()
+	first, months, err := calMonths(args, now)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if calWeekStart != "" {
+		cfg.WeekStart = calWeekStart
+	}
+	weekStart, err := cfg.WeekStartDay()
+	if err != nil {
+		return fmt.Errorf("invalid week start: %w", err)
+	}
+
+	ctx := context.Background()
+	svc, err := gcal.NewService(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("unable to create gcal service: %w", err)
+	}
+
+	end := first.AddDate(0, months, 0)
+	result, err := svc.ListEvents(ctx, gcal.ListOptions{
+		TimeMin: first.Format(time.RFC3339),
+		TimeMax: end.Format(time.RFC3339),
+		Partial: calPartial,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to retrieve events: %w", err)
+	}
+	reportCalendarErrors(result.Errors)
+
+	events := result.Events
+	if !calIncludeDeclined {
+		events = filterDeclinedEvents(events)
+	}
+
+	counts := make(map[string]int)
+	for day := first; day.Before(end); day = day.AddDate(0, 0, 1) {
+		counts[day.Format("2006-01-02")] = len(eventsOnDay(events, day))
+	}
+
+	r := &calRenderer{
+		weekStart: weekStart,
+		today:     now.Format("2006-01-02"),
+		counts:    counts,
+		shade:     calMark == "shade",
+		color:     useColor(calColor),
+	}
+	return r.render(os.Stdout, first, months, calYear || len(args) == 1)
+}
+
+// calMonths returns the first month to show and the number of months
+func calMonths(args []string, now time.Time) (time.Time, int, error) {
+	year, month := now.Year(), now.Month()
+
+	switch len(args) {
+	case 1:
+		y, err := strconv.Atoi(args[0])
+		if err != nil || y < 1 || y > 9999 {
+			return time.Time{}, 0, fmt.Errorf("invalid year: %s", args[0])
+		}
+		return time.Date(y, time.January, 1, 0, 0, 0, 0, now.Location()), 12, nil
+	case 2:
+		m, err := strconv.Atoi(args[0])
+		if err != nil || m < 1 || m > 12 {
+			return time.Time{}, 0, fmt.Errorf("invalid month: %s", args[0])
+		}
+		y, err := strconv.Atoi(args[1])
+		if err != nil || y < 1 || y > 9999 {
+			return time.Time{}, 0, fmt.Errorf("invalid year: %s", args[1])
+		}
+		year, month = y, time.Month(m)
+	}
+
+	first := time.Date(year, month, 1, 0, 0, 0, 0, now.Location())
+	switch {
+	case calYear:
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, now.Location()), 12, nil
+	case calThree:
+		return first.AddDate(0, -1, 0), 3, nil
+	default:
+		return first, 1, nil
+	}
+}
+
+// useColor decides whether to emit ANSI escape sequences for the --color option
+func useColor(mode string) bool {
+	switch mode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	fi, err := os.Stdout.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// calRenderer draws month grids
+type calRenderer struct {
+	weekStart time.Weekday
+	today     string
+	counts    map[string]int
+	shade     bool
+	color     bool
+}
+
+// shades are 256-color grayscale backgrounds for 1, 2, 3-4 and 5+ events
+var shades = []int{237, 240, 243, 246}
+
+var superscripts = []string{" ", "¹", "²", "³", "⁴", "⁵", "⁶", "⁷", "⁸", "⁹"}
+
+// render prints months starting at first, three per row.
+// If yearHeader is set, the year is printed once above the grid instead of in every month title.
+func (r *calRenderer) render(w io.Writer, first time.Time, months int, yearHeader bool) error {
+	if yearHeader {
+		width := min(months, 3)*calMonthWidth + (min(months, 3)-1)*2
+		fmt.Fprintln(w, strings.TrimRight(center(strconv.Itoa(first.Year()), width), " "))
+		fmt.Fprintln(w)
+	}
+
+	for row := 0; row < months; row += 3 {
+		var blocks [][]string
+		for i := row; i < min(row+3, months); i++ {
+			blocks = append(blocks, r.month(first.AddDate(0, i, 0), !yearHeader))
+		}
+
+		lines := 0
+		for _, b := range blocks {
+			lines = max(lines, len(b))
+		}
+		for l := 0; l < lines; l++ {
+			cells := make([]string, len(blocks))
+			for i, b := range blocks {
+				if l < len(b) {
+					cells[i] = b[l]
+				} else {
+					cells[i] = strings.Repeat(" ", calMonthWidth)
+				}
+			}
+			if _, err := fmt.Fprintln(w, strings.TrimRight(strings.Join(cells, "  "), " ")); err != nil {
+				return err
+			}
+		}
+		if row+3 < months {
+			fmt.Fprintln(w)
+		}
+	}
+	return nil
+}
+
+// month returns the lines of one month grid, each calMonthWidth characters wide
+func (r *calRenderer) month(first time.Time, withYear bool) []string {
+	title := first.Format("January")
+	if withYear {
+		title = first.Format("January 2006")
+	}
+	lines := []string{center(title, calMonthWidth)}
+
+	var header strings.Builder
+	for i := 0; i < 7; i++ {
+		header.WriteString(" " + time.Weekday((int(r.weekStart)+i)%7).String()[:2] + " ")
+	}
+	lines = append(lines, header.String())
+
+	offset := (int(first.Weekday()) - int(r.weekStart) + 7) % 7
+	var line strings.Builder
+	line.WriteString(strings.Repeat(" ", offset*4))
+	col := offset
+	for day := first; day.Month() == first.Month(); day = day.AddDate(0, 0, 1) {
+		line.WriteString(r.cell(day))
+		col++
+		if col == 7 {
+			lines = append(lines, line.String())
+			line.Reset()
+			col = 0
+		}
+	}
+	if col > 0 {
+		line.WriteString(strings.Repeat(" ", (7-col)*4))
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+// cell renders a day as 4 visible characters: a marker, the day number and the event count
+func (r *calRenderer) cell(day time.Time) string {
+	key := day.Format("2006-01-02")
+	count := r.counts[key]
+	isToday := key == r.today
+
+	marker := " "
+	if isToday && !r.color {
+		marker = ">"
+	}
+
+	suffix := " "
+	if count > 0 && (!r.shade || !r.color) {
+		suffix = "+"
+		if count < len(superscripts) {
+			suffix = superscripts[count]
+		}
+	}
+
+	number := fmt.Sprintf("%2d", day.Day())
+	if r.color {
+		var codes []string
+		if isToday {
+			codes = append(codes, "7")
+		}
+		if r.shade && count > 0 {
+			codes = append(codes, "48;5;"+strconv.Itoa(shades[min(shadeLevel(count), len(shades)-1)]))
+		}
+		if len(codes) > 0 {
+			number = "\x1b[" + strings.Join(codes, ";") + "m" + number + "\x1b[0m"
+		}
+	}
+
+	return marker + number + suffix
+}
+
+// shadeLevel maps an event count to an index into shades
+func shadeLevel(count int) int {
+	switch {
+	case count <= 2:
+		return count - 1
+	case count <= 4:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// center pads s with spaces to width, centering it
+func center(s string, width int) string {
+	n := len([]rune(s))
+	if n >= width {
+		return s
+	}
+	left := (width - n) / 2
+	return strings.Repeat(" ", left) + s + strings.Repeat(" ", width-n-left)
+}
+
+func init() {
+	rootCmd.AddCommand(calCmd)
+	calCmd.Flags().BoolVarP(&calThree, "three", "3", false, "Show the previous, current and next month")
+	calCmd.Flags().BoolVarP(&calYear, "year", "y", false, "Show the whole year")
+	calCmd.Flags().StringVar(&calMark, "mark", "count", "How to mark busy days: count, shade")
+	calCmd.Flags().StringVar(&calColor, "color", "auto", "Use colors: auto, always, never")
+	calCmd.Flags().StringVar(&calWeekStart, "week-start", "", "First day of the week (default from week_start config, monday)")
+	calCmd.Flags().BoolVar(&calIncludeDeclined, "include-declined", false, "Count declined events")
+	calCmd.Flags().BoolVar(&calPartial, "partial", false, "Show the calendar even if some calendars cannot be fetched")
+}
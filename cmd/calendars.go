@@ -0,0 +1,321 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/longkey1/gcal/internal/gcal"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/calendar/v3"
+)
+
+var (
+	calendarsOutput      string
+	calendarsShowHidden  bool
+	calendarsDescription string
+	calendarsTimeZone    string
+	calendarsYes         bool
+)
+
+var calendarsCmd = &cobra.Command{
+	Use:   "calendars",
+	Short: "Manage calendars",
+	Long: `List, create, delete and rename calendars, and subscribe to or unsubscribe
+from calendars shared with you.
+Listing only needs read access; the other commands require "gcal auth --manage"
+for OAuth.`,
+	Example: `  # Find the IDs to put in calendar_id_list
+  gcal calendars list
+
+  # Create a calendar
+  gcal calendars create "Side project"
+
+  # Subscribe to a shared calendar
+  gcal calendars subscribe team@example.com`,
+}
+
+var calendarsListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the calendars in your calendar list",
+	Long: `List the calendars in your calendar list with their ID, name, your access role,
+time zone and color. The primary calendar is marked in the PRIMARY column.`,
+	Example: `  # List calendars
+  gcal calendars list
+
+  # Include hidden calendars, as JSON
+  gcal calendars list --show-hidden -o json`,
+	Args:    cobra.NoArgs,
+	PreRunE: validateCalendarsOutput,
+	RunE:    runCalendarsList,
+}
+
+var calendarsCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a secondary calendar",
+	Example: `  # Create a calendar
+  gcal calendars create "Side project"
+
+  # Create a calendar in another time zone
+  gcal calendars create "NY office" --time-zone America/New_York --description "Events in New York"`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: validateCalendarsOutput,
+	RunE:    runCalendarsCreate,
+}
+
+var calendarsDeleteCmd = &cobra.Command{
+	Use:   "delete <calendar-id>",
+	Short: "Delete a secondary calendar and all its events",
+	Long: `Delete a secondary calendar you own, together with all its events.
+The primary calendar cannot be deleted. To stop seeing a calendar shared with
+you, use "gcal calendars unsubscribe" instead.`,
+	Example: `  # Delete a calendar (asks for confirmation)
+  gcal calendars delete abc123@group.calendar.google.com`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCalendarsDelete,
+}
+
+var calendarsRenameCmd = &cobra.Command{
+	Use:   "rename <calendar-id> <name>",
+	Short: "Rename a calendar",
+	Example: `  # Rename a calendar
+  gcal calendars rename abc123@group.calendar.google.com "Side project (archived)"`,
+	Args: cobra.ExactArgs(2),
+	RunE: runCalendarsRename,
+}
+
+var calendarsSubscribeCmd = &cobra.Command{
+	Use:   "subscribe <calendar-id>",
+	Short: "Add a calendar shared with you to your calendar list",
+	Example: `  # Subscribe to a colleague's calendar
+  gcal calendars subscribe alice@example.com`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: validateCalendarsOutput,
+	RunE:    runCalendarsSubscribe,
+}
+
+var calendarsUnsubscribeCmd = &cobra.Command{
+	Use:   "unsubscribe <calendar-id>",
+	Short: "Remove a calendar from your calendar list",
+	Long: `Remove a calendar from your calendar list. The calendar itself and its events
+are kept.`,
+	Example: `  # Unsubscribe from a colleague's calendar
+  gcal calendars unsubscribe alice@example.com`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCalendarsUnsubscribe,
+}
+
+func validateCalendarsOutput(cmd *cobra.Command, args []string) error {
+	if calendarsOutput != "table" && calendarsOutput != "json" {
+		return fmt.Errorf("invalid output format: %s (valid: table, json)", calendarsOutput)
+	}
+	return nil
+}
+
+func runCalendarsList(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	svc, err := gcal.NewService(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("unable to create gcal service: %w", err)
+	}
+
+	calendars, err := svc.ListCalendars(ctx, calendarsShowHidden)
+	if err != nil {
+		return fmt.Errorf("unable to retrieve calendars: %w", err)
+	}
+	// The primary calendar first, then by name
+	sort.SliceStable(calendars, func(i, j int) bool {
+		if calendars[i].Primary != calendars[j].Primary {
+			return calendars[i].Primary
+		}
+		return calendarName(calendars[i]) < calendarName(calendars[j])
+	})
+
+	return outputCalendars(os.Stdout, calendars)
+}
+
+func runCalendarsCreate(cmd *cobra.Command, args []string) error {
+	if calendarsTimeZone != "" {
+		if _, err := time.LoadLocation(calendarsTimeZone); err != nil {
+			return fmt.Errorf("invalid time zone: %w", err)
+		}
+	}
+
+	svc, err := newManageService()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	created, err := svc.Calendar.Calendars.Insert(&calendar.Calendar{
+		Summary:     args[0],
+		Description: calendarsDescription,
+		TimeZone:    calendarsTimeZone,
+	}).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("unable to create calendar: %w", wrapManageError(err))
+	}
+
+	// The calendar list entry has the access role and color of the new calendar
+	entry, err := svc.Calendar.CalendarList.Get(created.Id).Context(ctx).Do()
+	if err != nil {
+		entry = &calendar.CalendarListEntry{Id: created.Id, Summary: created.Summary, TimeZone: created.TimeZone, AccessRole: "owner"}
+	}
+	return outputCalendars(os.Stdout, []*calendar.CalendarListEntry{entry})
+}
+
+func runCalendarsDelete(cmd *cobra.Command, args []string) error {
+	svc, err := newManageService()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	entry, err := svc.Calendar.CalendarList.Get(args[0]).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("unable to find calendar: %w", err)
+	}
+	if entry.Primary {
+		return fmt.Errorf("the primary calendar cannot be deleted")
+	}
+	if entry.AccessRole != "owner" {
+		return fmt.Errorf("only calendars you own can be deleted (use 'gcal calendars unsubscribe %s' to remove it from your list)", args[0])
+	}
+
+	if !calendarsYes {
+		fmt.Printf("Calendar: %s (%s)\n", calendarName(entry), entry.Id)
+		if !confirm("Delete this calendar and all its events?") {
+			fmt.Println("Cancelled.")
+			return nil
+		}
+	}
+
+	if err := svc.Calendar.Calendars.Delete(entry.Id).Context(ctx).Do(); err != nil {
+		return fmt.Errorf("unable to delete calendar: %w", wrapManageError(err))
+	}
+	fmt.Println("Calendar deleted.")
+	return nil
+}
+
+func runCalendarsRename(cmd *cobra.Command, args []string) error {
+	svc, err := newManageService()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	updated, err := svc.Calendar.Calendars.Patch(args[0], &calendar.Calendar{Summary: args[1]}).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("unable to rename calendar: %w", wrapManageError(err))
+	}
+	fmt.Printf("Calendar %s renamed to %s.\n", updated.Id, updated.Summary)
+	return nil
+}
+
+func runCalendarsSubscribe(cmd *cobra.Command, args []string) error {
+	svc, err := newManageService()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	entry, err := svc.Calendar.CalendarList.Insert(&calendar.CalendarListEntry{Id: args[0]}).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("unable to subscribe to calendar: %w", wrapManageError(err))
+	}
+	return outputCalendars(os.Stdout, []*calendar.CalendarListEntry{entry})
+}
+
+func runCalendarsUnsubscribe(cmd *cobra.Command, args []string) error {
+	svc, err := newManageService()
+	if err != nil {
+		return err
+	}
+
+	if err := svc.Calendar.CalendarList.Delete(args[0]).Context(context.Background()).Do(); err != nil {
+		return fmt.Errorf("unable to unsubscribe from calendar: %w", wrapManageError(err))
+	}
+	fmt.Printf("Unsubscribed from %s.\n", args[0])
+	return nil
+}
+
+func newManageService() (*gcal.Service, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	svc, err := gcal.NewManageService(context.Background(), cfg)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create gcal service: %w", err)
+	}
+	return svc, nil
+}
+
+// calendarName returns the name the user gave a calendar, or its own name
+func calendarName(c *calendar.CalendarListEntry) string {
+	if c.SummaryOverride != "" {
+		return c.SummaryOverride
+	}
+	return c.Summary
+}
+
+func outputCalendars(w io.Writer, calendars []*calendar.CalendarListEntry) error {
+	if calendarsOutput == "json" {
+		b, err := json.Marshal(calendars)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s", b)
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSUMMARY\tROLE\tTIMEZONE\tCOLOR\tPRIMARY")
+	for _, c := range calendars {
+		primary := ""
+		if c.Primary {
+			primary = "*"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", c.Id, calendarName(c), c.AccessRole, c.TimeZone, c.BackgroundColor, primary)
+	}
+	return tw.Flush()
+}
+
+func init() {
+	rootCmd.AddCommand(calendarsCmd)
+	calendarsCmd.AddCommand(calendarsListCmd, calendarsCreateCmd, calendarsDeleteCmd, calendarsRenameCmd, calendarsSubscribeCmd, calendarsUnsubscribeCmd)
+
+	for _, c := range []*cobra.Command{calendarsListCmd, calendarsCreateCmd, calendarsSubscribeCmd} {
+		c.Flags().StringVarP(&calendarsOutput, "output", "o", "table", "Output format: table, json")
+	}
+	calendarsListCmd.Flags().BoolVar(&calendarsShowHidden, "show-hidden", false, "Include calendars hidden from the calendar list")
+	calendarsCreateCmd.Flags().StringVar(&calendarsDescription, "description", "", "Description of the calendar")
+	calendarsCreateCmd.Flags().StringVar(&calendarsTimeZone, "time-zone", "", "Time zone of the calendar, e.g. Asia/Tokyo (default: the time zone of your account)")
+	calendarsDeleteCmd.Flags().BoolVarP(&calendarsYes, "yes", "y", false, "Delete without confirmation")
+}
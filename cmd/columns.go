@@ -0,0 +1,216 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// defaultColumns are the table columns used when none are configured
+var defaultColumns = []string{"start", "end", "title"}
+
+// tableColumn is a column of the table output
+type tableColumn struct {
+	name  string
+	width int // maximum width in characters, 0 for no limit
+}
+
+// columnRow is the context a row of the table output is rendered in
+type columnRow struct {
+	event    *calendar.Event
+	calendar string
+	// day is set when rendering a day of a grouped view
+	day *time.Time
+}
+
+// columnValues renders the value of each available column
+var columnValues = map[string]func(r columnRow) string{
+	"date": func(r columnRow) string {
+		if r.day != nil {
+			return r.day.Format("2006-01-02")
+		}
+		if t, _, err := eventDateTimeValue(r.event.Start); err == nil {
+			return t.Local().Format("2006-01-02")
+		}
+		return ""
+	},
+	"start": func(r columnRow) string {
+		if r.day != nil {
+			if s, _, allDay, err := eventSpan(r.event); err == nil && !allDay && s.Before(*r.day) {
+				return ".."
+			}
+		}
+		return formatEventTime(r.event.Start)
+	},
+	"end": func(r columnRow) string {
+		if r.day != nil {
+			if _, en, allDay, err := eventSpan(r.event); err == nil && !allDay && en.After(r.day.AddDate(0, 0, 1)) {
+				return ".."
+			}
+		}
+		return formatEventTime(r.event.End)
+	},
+	"duration": func(r columnRow) string {
+		return formatEventDuration(r.event)
+	},
+	"title": func(r columnRow) string {
+		return r.event.Summary
+	},
+	"calendar": func(r columnRow) string {
+		return r.calendar
+	},
+	"location": func(r columnRow) string {
+		return r.event.Location
+	},
+	"organizer": func(r columnRow) string {
+		if r.event.Organizer == nil {
+			return ""
+		}
+		if r.event.Organizer.DisplayName != "" {
+			return r.event.Organizer.DisplayName
+		}
+		return r.event.Organizer.Email
+	},
+	"attendees": func(r columnRow) string {
+		return strconv.Itoa(len(r.event.Attendees))
+	},
+	"response": func(r columnRow) string {
+		for _, a := range r.event.Attendees {
+			if a.Self {
+				return a.ResponseStatus
+			}
+		}
+		return ""
+	},
+	"conference": func(r columnRow) string {
+		return conferenceLink(r.event)
+	},
+	"id": func(r columnRow) string {
+		return r.event.Id
+	},
+	"updated": func(r columnRow) string {
+		t, err := time.Parse(time.RFC3339, r.event.Updated)
+		if err != nil {
+			return r.event.Updated
+		}
+		return t.Local().Format("2006-01-02 15:04")
+	},
+	"status": func(r columnRow) string {
+		return r.event.Status
+	},
+}
+
+// columnNames lists the available columns in the order they are documented
+var columnNames = []string{"date", "start", "end", "duration", "title", "calendar", "location", "organizer", "attendees", "response", "conference", "id", "updated", "status"}
+
+// parseColumns parses column specs of the form name or name:width
+func parseColumns(specs []string) ([]tableColumn, error) {
+	columns := make([]tableColumn, 0, len(specs))
+	for _, spec := range specs {
+		name, width, hasWidth := strings.Cut(strings.TrimSpace(spec), ":")
+		name = strings.ToLower(name)
+		if _, ok := columnValues[name]; !ok {
+			return nil, fmt.Errorf("unknown column: %s (valid: %s)", name, strings.Join(columnNames, ", "))
+		}
+		col := tableColumn{name: name}
+		if hasWidth {
+			w, err := strconv.Atoi(width)
+			if err != nil || w < 1 {
+				return nil, fmt.Errorf("invalid width for column %s: %s", name, width)
+			}
+			col.width = w
+		}
+		columns = append(columns, col)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no columns given")
+	}
+	return columns, nil
+}
+
+// tableHeader returns the tab-separated header line for columns
+func tableHeader(columns []tableColumn) string {
+	cells := make([]string, len(columns))
+	for i, c := range columns {
+		cells[i] = truncate(strings.ToUpper(c.name), c.width)
+	}
+	return strings.Join(cells, "\t")
+}
+
+// tableRow returns the tab-separated line for a row
+func tableRow(columns []tableColumn, r columnRow) string {
+	cells := make([]string, len(columns))
+	for i, c := range columns {
+		value := strings.Join(strings.Fields(columnValues[c.name](r)), " ")
+		cells[i] = truncate(value, c.width)
+	}
+	return strings.Join(cells, "\t")
+}
+
+// truncate shortens s to width characters, ending with an ellipsis when cut
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if width <= 0 || len(runes) <= width {
+		return s
+	}
+	if width == 1 {
+		return "…"
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+// formatEventDuration formats the length of an event, e.g. "1h30m" or "2d" for all-day events
+func formatEventDuration(e *calendar.Event) string {
+	start, end, allDay, err := eventSpan(e)
+	if err != nil {
+		return ""
+	}
+	if allDay {
+		return fmt.Sprintf("%dd", int(end.Sub(start).Round(24*time.Hour)/(24*time.Hour)))
+	}
+	return formatDuration(end.Sub(start))
+}
+
+// formatDuration formats a duration in hours and minutes, e.g. "45m", "2h" or "1h30m"
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	h, m := int(d.Hours()), int(d.Minutes())%60
+	switch {
+	case h == 0:
+		return fmt.Sprintf("%dm", m)
+	case m == 0:
+		return fmt.Sprintf("%dh", h)
+	default:
+		return fmt.Sprintf("%dh%dm", h, m)
+	}
+}
+
+// conferenceLink returns the video conference URL of an event, if any
+func conferenceLink(e *calendar.Event) string {
+	if e.ConferenceData != nil {
+		for _, ep := range e.ConferenceData.EntryPoints {
+			if ep.EntryPointType == "video" {
+				return ep.Uri
+			}
+		}
+	}
+	return e.HangoutLink
+}
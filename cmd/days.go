@@ -0,0 +1,111 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/longkey1/gcal/internal/dateexpr"
+	"google.golang.org/api/calendar/v3"
+)
+
+// eventSpan returns the start and end of an event.
+// The end of an all-day event is exclusive, i.e. midnight of the following day.
+func eventSpan(e *calendar.Event) (time.Time, time.Time, bool, error) {
+	start, allDay, err := eventDateTimeValue(e.Start)
+	if err != nil {
+		return time.Time{}, time.Time{}, false, err
+	}
+	end, _, err := eventDateTimeValue(e.End)
+	if err != nil {
+		return time.Time{}, time.Time{}, false, err
+	}
+	return start, end, allDay, nil
+}
+
+// eventsOnDay returns the events that take place at least partly on the day starting at day
+func eventsOnDay(events []*calendar.Event, day time.Time) []*calendar.Event {
+	next := day.AddDate(0, 0, 1)
+	onDay := make([]*calendar.Event, 0)
+	for _, e := range events {
+		start, end, _, err := eventSpan(e)
+		if err != nil {
+			continue
+		}
+		// Zero-length events belong to the day they start on
+		if start.Before(next) && (end.After(day) || (start.Equal(end) && !start.Before(day))) {
+			onDay = append(onDay, e)
+		}
+	}
+	return onDay
+}
+
+// outputDayTable prints events grouped under a heading for every day in r, below a single
+// column header row unless opts.noHeader is set. Days without events are shown explicitly and
+// events spanning several days are shown on each of them, with ".." marking a start or end on
+// another day. Columns are aligned across all days.
+func outputDayTable(w io.Writer, events []*calendar.Event, r dateexpr.Range, opts *outputOptions) error {
+	type dayEvents struct {
+		day    time.Time
+		events []*calendar.Event
+	}
+	var days []dayEvents
+	for day := r.Start; day.Before(r.End); day = day.AddDate(0, 0, 1) {
+		days = append(days, dayEvents{day: day, events: eventsOnDay(events, day)})
+	}
+
+	// The header and the rows of every day are aligned together, then split up by day
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	if !opts.noHeader {
+		fmt.Fprintf(tw, "  %s\n", tableHeader(opts.columns))
+	}
+	for _, d := range days {
+		for _, e := range d.events {
+			fmt.Fprintf(tw, "  %s\n", tableRow(opts.columns, columnRow{event: e, calendar: opts.calendars[e], day: &d.day}))
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	lines := strings.SplitAfter(buf.String(), "\n")
+
+	var out strings.Builder
+	if !opts.noHeader {
+		out.WriteString(lines[0])
+		lines = lines[1:]
+	}
+	for i, d := range days {
+		if i > 0 {
+			out.WriteString("\n")
+		}
+		fmt.Fprintf(&out, "%s %s\n", d.day.Format("2006-01-02"), d.day.Format("Mon"))
+
+		if len(d.events) == 0 {
+			out.WriteString("  (no events)\n")
+			continue
+		}
+		out.WriteString(strings.Join(lines[:len(d.events)], ""))
+		lines = lines[len(d.events):]
+	}
+	_, err := io.WriteString(w, out.String())
+	return err
+}
@@ -0,0 +1,151 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/longkey1/gcal/internal/gcal"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/calendar/v3"
+)
+
+var (
+	deleteCalendar string
+	deleteScope    string
+	deleteYes      bool
+)
+
+var deleteCmd = &cobra.Command{
+	Use:     "delete <event-id>",
+	Aliases: []string{"rm"},
+	Short:   "Delete a calendar event",
+	Long: `Delete an existing event by ID.
+The calendar that owns the event is looked up in calendar_id_list unless
+--calendar is given. For recurring events, --scope selects whether only this
+occurrence, this and all following occurrences, or the whole series is deleted.
+The ID of a series rather than of an occurrence requires --scope all.`,
+	Example: `  # Delete an event (asks for confirmation)
+  gcal delete abc123
+
+  # Delete without confirmation
+  gcal delete abc123 --yes
+
+  # Delete this and all following occurrences of a recurring event
+  gcal delete abc123_20240115T010000Z --scope following`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: validateDeleteFlags,
+	RunE:    runDelete,
+}
+
+func validateDeleteFlags(cmd *cobra.Command, args []string) error {
+	return validateRecurrenceScope(deleteScope)
+}
+
+func runDelete(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	svc, err := gcal.NewWriteService(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("unable to create gcal service: %w", err)
+	}
+
+	cid, event, err := svc.FindEvent(ctx, deleteCalendar, args[0])
+	if err != nil {
+		return fmt.Errorf("unable to find event: %w", err)
+	}
+
+	scope, err := recurrenceScope(event, deleteScope)
+	if err != nil {
+		return err
+	}
+
+	if !deleteYes {
+		fmt.Printf("Event: %s (%s)\n", event.Summary, formatEventDateTime(event.Start))
+		if len(event.Recurrence) > 0 {
+			fmt.Printf("Repeats: %s\n", gcal.DescribeRecurrence(event.Recurrence))
+		}
+		if !confirm(deletePrompt(scope)) {
+			fmt.Println("Cancelled.")
+			return nil
+		}
+	}
+
+	switch scope {
+	case scopeThis:
+		err = svc.Calendar.Events.Delete(cid, event.Id).Do()
+	case scopeAll:
+		err = svc.Calendar.Events.Delete(cid, seriesID(event)).Do()
+	case scopeFollowing:
+		err = deleteFollowing(ctx, svc, cid, event)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to delete event: %w", wrapWriteError(err))
+	}
+
+	fmt.Println("Event deleted.")
+	return nil
+}
+
+func deletePrompt(scope string) string {
+	switch scope {
+	case scopeAll:
+		return "Delete all occurrences of this recurring event?"
+	case scopeFollowing:
+		return "Delete this and all following occurrences?"
+	default:
+		return "Delete this event?"
+	}
+}
+
+// deleteFollowing ends the series just before the occurrence
+func deleteFollowing(ctx context.Context, svc *gcal.Service, cid string, event *calendar.Event) error {
+	master, err := svc.Calendar.Events.Get(cid, event.RecurringEventId).Do()
+	if err != nil {
+		return err
+	}
+
+	split, allDay, err := eventDateTimeValue(event.OriginalStartTime)
+	if err != nil {
+		return fmt.Errorf("invalid original start time: %w", err)
+	}
+
+	before, err := svc.CountInstancesBefore(ctx, cid, master.Id, split.Format(time.RFC3339))
+	if err != nil {
+		return err
+	}
+	if before == 0 {
+		// Deleting from the first occurrence removes the whole series
+		return svc.Calendar.Events.Delete(cid, master.Id).Do()
+	}
+
+	head, _ := gcal.SplitRecurrence(master.Recurrence, split, allDay, before)
+	_, err = svc.Calendar.Events.Patch(cid, master.Id, &calendar.Event{Recurrence: head}).Do()
+	return err
+}
+
+func init() {
+	rootCmd.AddCommand(deleteCmd)
+	deleteCmd.Flags().StringVar(&deleteCalendar, "calendar", "", "Calendar ID the event belongs to (default: search calendar_id_list)")
+	deleteCmd.Flags().StringVar(&deleteScope, "scope", scopeThis, "Occurrences of a recurring event to delete: this, following, all")
+	deleteCmd.Flags().BoolVarP(&deleteYes, "yes", "y", false, "Delete without confirmation")
+}
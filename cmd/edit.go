@@ -0,0 +1,389 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/longkey1/gcal/internal/gcal"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/calendar/v3"
+)
+
+var (
+	editTitle       string
+	editStart       string
+	editEnd         string
+	editDuration    time.Duration
+	editAllDay      bool
+	editLocation    string
+	editDescription string
+	editAttendees   []string
+	editCalendar    string
+	editScope       string
+	editOutput      string
+)
+
+var editCmd = &cobra.Command{
+	Use:   "edit <event-id>",
+	Short: "Edit a calendar event",
+	Long: `Edit an existing event by ID.
+Only the given fields are changed. The calendar that owns the event is looked up
+in calendar_id_list unless --calendar is given.
+For recurring events, --scope selects whether only this occurrence, this and
+all following occurrences, or the whole series is changed. The ID of a series
+rather than of an occurrence requires --scope all.`,
+	Example: `  # Rename an event
+  gcal edit abc123 --title "Team Sync"
+
+  # Move an event, keeping its duration
+  gcal edit abc123 --start "2024-01-15 11:00"
+
+  # Clear the location
+  gcal edit abc123 --location ""
+
+  # Move every occurrence of a recurring event by one hour
+  gcal edit abc123_20240115T010000Z --start "2024-01-15 11:00" --scope all`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: validateEditFlags,
+	RunE:    runEdit,
+}
+
+func validateEditFlags(cmd *cobra.Command, args []string) error {
+	changed := false
+	for _, name := range []string{"title", "start", "end", "duration", "all-day", "location", "description", "attendee"} {
+		if cmd.Flags().Lookup(name).Changed {
+			changed = true
+		}
+	}
+	if !changed {
+		return fmt.Errorf("nothing to change")
+	}
+
+	// Validate that --end and --duration are not used together
+	if cmd.Flags().Lookup("end").Changed && cmd.Flags().Lookup("duration").Changed {
+		return fmt.Errorf("cannot use --end and --duration together")
+	}
+
+	if editDuration < 0 {
+		return fmt.Errorf("--duration must be positive")
+	}
+
+	if err := validateRecurrenceScope(editScope); err != nil {
+		return err
+	}
+
+	return validateOutputFormat(editOutput, resultOutputFormats)
+}
+
+func runEdit(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	svc, err := gcal.NewWriteService(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("unable to create gcal service: %w", err)
+	}
+
+	cid, event, err := svc.FindEvent(ctx, editCalendar, args[0])
+	if err != nil {
+		return fmt.Errorf("unable to find event: %w", err)
+	}
+
+	scope, err := recurrenceScope(event, editScope)
+	if err != nil {
+		return err
+	}
+
+	var updated *calendar.Event
+	switch scope {
+	case scopeThis:
+		updated, err = editSingleEvent(cmd, svc, cid, event)
+	case scopeAll:
+		updated, err = editSeries(cmd, svc, cid, event)
+	case scopeFollowing:
+		updated, err = editFollowing(ctx, cmd, svc, cid, event)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to edit event: %w", wrapWriteError(err))
+	}
+
+	if err := outputEvents(os.Stdout, []*calendar.Event{updated}, editOutput, newOutputOptions(map[*calendar.Event]string{updated: cid})); err != nil {
+		return fmt.Errorf("unable to output event: %w", err)
+	}
+
+	return nil
+}
+
+// editSingleEvent patches a single event or a single occurrence of a recurring event
+func editSingleEvent(cmd *cobra.Command, svc *gcal.Service, cid string, event *calendar.Event) (*calendar.Event, error) {
+	patch := &calendar.Event{}
+	applyEditFields(cmd, patch)
+
+	if editTimesChanged(cmd) {
+		start, end, allDay, err := editTimes(cmd, event)
+		if err != nil {
+			return nil, err
+		}
+		patch.Start = patchEventDateTime(start, allDay)
+		patch.End = patchEventDateTime(end, allDay)
+	}
+
+	return svc.Calendar.Events.Patch(cid, event.Id, patch).Do()
+}
+
+// editSeries patches the recurring event that event is an occurrence of, or is itself
+func editSeries(cmd *cobra.Command, svc *gcal.Service, cid string, event *calendar.Event) (*calendar.Event, error) {
+	master := event
+	var err error
+	if event.RecurringEventId != "" {
+		master, err = svc.Calendar.Events.Get(cid, event.RecurringEventId).Do()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	patch := &calendar.Event{}
+	applyEditFields(cmd, patch)
+
+	if editTimesChanged(cmd) {
+		patch.Start, patch.End, err = shiftSeriesTimes(cmd, event, master.Start, master.End)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return svc.Calendar.Events.Patch(cid, master.Id, patch).Do()
+}
+
+// editFollowing ends the series before the occurrence and starts a new, edited series from it
+func editFollowing(ctx context.Context, cmd *cobra.Command, svc *gcal.Service, cid string, event *calendar.Event) (*calendar.Event, error) {
+	master, err := svc.Calendar.Events.Get(cid, event.RecurringEventId).Do()
+	if err != nil {
+		return nil, err
+	}
+
+	split, allDay, err := eventDateTimeValue(event.OriginalStartTime)
+	if err != nil {
+		return nil, fmt.Errorf("invalid original start time: %w", err)
+	}
+
+	before, err := svc.CountInstancesBefore(ctx, cid, master.Id, split.Format(time.RFC3339))
+	if err != nil {
+		return nil, err
+	}
+	if before == 0 {
+		// Editing from the first occurrence is the same as editing the whole series
+		return editSeries(cmd, svc, cid, event)
+	}
+
+	head, tail := gcal.SplitRecurrence(master.Recurrence, split, allDay, before)
+
+	// The new series starts at the original time of the occurrence with the duration of the series
+	masterStart, _, err := eventDateTimeValue(master.Start)
+	if err != nil {
+		return nil, err
+	}
+	start, err := shiftEventDateTime(master.Start, split.Sub(masterStart))
+	if err != nil {
+		return nil, err
+	}
+	end, err := shiftEventDateTime(master.End, split.Sub(masterStart))
+	if err != nil {
+		return nil, err
+	}
+
+	series := &calendar.Event{
+		Summary:      master.Summary,
+		Description:  master.Description,
+		Location:     master.Location,
+		Attendees:    master.Attendees,
+		Reminders:    master.Reminders,
+		Transparency: master.Transparency,
+		Visibility:   master.Visibility,
+		ColorId:      master.ColorId,
+		Recurrence:   tail,
+		Start:        start,
+		End:          end,
+	}
+	applyEditFields(cmd, series)
+
+	if editTimesChanged(cmd) {
+		series.Start, series.End, err = shiftSeriesTimes(cmd, event, start, end)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	created, err := svc.Calendar.Events.Insert(cid, series).Do()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := svc.Calendar.Events.Patch(cid, master.Id, &calendar.Event{Recurrence: head}).Do(); err != nil {
+		return nil, fmt.Errorf("created new series %s but unable to end the original series: %w", created.Id, err)
+	}
+
+	return created, nil
+}
+
+// applyEditFields copies the non-time fields given on the command line to e.
+// Fields set to an empty value are force-sent so that patches clear them.
+func applyEditFields(cmd *cobra.Command, e *calendar.Event) {
+	flags := cmd.Flags()
+	if flags.Lookup("title").Changed {
+		e.Summary = editTitle
+		e.ForceSendFields = append(e.ForceSendFields, "Summary")
+	}
+	if flags.Lookup("location").Changed {
+		e.Location = editLocation
+		e.ForceSendFields = append(e.ForceSendFields, "Location")
+	}
+	if flags.Lookup("description").Changed {
+		e.Description = editDescription
+		e.ForceSendFields = append(e.ForceSendFields, "Description")
+	}
+	if flags.Lookup("attendee").Changed {
+		e.Attendees = newAttendees(editAttendees)
+		e.ForceSendFields = append(e.ForceSendFields, "Attendees")
+	}
+}
+
+func editTimesChanged(cmd *cobra.Command) bool {
+	flags := cmd.Flags()
+	return flags.Lookup("start").Changed || flags.Lookup("end").Changed ||
+		flags.Lookup("duration").Changed || flags.Lookup("all-day").Changed
+}
+
+// editTimes computes the new start and end of event from the time flags.
+// Unchanged values are taken from the event, and moving the start keeps the duration.
+func editTimes(cmd *cobra.Command, event *calendar.Event) (time.Time, time.Time, bool, error) {
+	curStart, curAllDay, err := eventDateTimeValue(event.Start)
+	if err != nil {
+		return time.Time{}, time.Time{}, false, fmt.Errorf("invalid event start: %w", err)
+	}
+	curEnd, _, err := eventDateTimeValue(event.End)
+	if err != nil {
+		return time.Time{}, time.Time{}, false, fmt.Errorf("invalid event end: %w", err)
+	}
+
+	start, allDay := curStart, curAllDay
+	if cmd.Flags().Lookup("all-day").Changed {
+		allDay = editAllDay
+	}
+	if editStart != "" {
+		var dateOnly bool
+		start, dateOnly, err = parseEventTime(editStart)
+		if err != nil {
+			return time.Time{}, time.Time{}, false, fmt.Errorf("invalid --start: %w", err)
+		}
+		if dateOnly {
+			allDay = true
+		} else if !cmd.Flags().Lookup("all-day").Changed {
+			allDay = false
+		}
+	}
+
+	var end time.Time
+	switch {
+	case editEnd != "":
+		end, _, err = parseEventTime(editEnd)
+		if err != nil {
+			return time.Time{}, time.Time{}, false, fmt.Errorf("invalid --end: %w", err)
+		}
+		if allDay {
+			// All-day end dates are exclusive in the API, --end is inclusive
+			end = end.AddDate(0, 0, 1)
+		}
+	case editDuration > 0:
+		if allDay {
+			return time.Time{}, time.Time{}, false, fmt.Errorf("--duration cannot be used with all-day events, use --end instead")
+		}
+		end = start.Add(editDuration)
+	case allDay != curAllDay:
+		if allDay {
+			end = start.AddDate(0, 0, 1)
+		} else {
+			end = start.Add(time.Hour)
+		}
+	default:
+		end = start.Add(curEnd.Sub(curStart))
+	}
+
+	if allDay {
+		start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+		end = time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, end.Location())
+	}
+
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, false, fmt.Errorf("end must be after start")
+	}
+
+	return start, end, allDay, nil
+}
+
+// shiftSeriesTimes moves the series times start and end by the same amount the time flags
+// move the occurrence event
+func shiftSeriesTimes(cmd *cobra.Command, event *calendar.Event, start, end *calendar.EventDateTime) (*calendar.EventDateTime, *calendar.EventDateTime, error) {
+	newStart, newEnd, allDay, err := editTimes(cmd, event)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	curStart, curAllDay, err := eventDateTimeValue(event.Start)
+	if err != nil {
+		return nil, nil, err
+	}
+	curEnd, _, err := eventDateTimeValue(event.End)
+	if err != nil {
+		return nil, nil, err
+	}
+	if allDay != curAllDay {
+		return nil, nil, fmt.Errorf("cannot switch between all-day and timed events for multiple occurrences")
+	}
+
+	shiftedStart, err := shiftEventDateTime(start, newStart.Sub(curStart))
+	if err != nil {
+		return nil, nil, err
+	}
+	shiftedEnd, err := shiftEventDateTime(end, newEnd.Sub(curEnd))
+	if err != nil {
+		return nil, nil, err
+	}
+	return shiftedStart, shiftedEnd, nil
+}
+
+func init() {
+	rootCmd.AddCommand(editCmd)
+	editCmd.Flags().StringVar(&editTitle, "title", "", "New event title")
+	editCmd.Flags().StringVarP(&editStart, "start", "s", "", "New start time (YYYY-MM-DD HH:MM, or YYYY-MM-DD for all-day events)")
+	editCmd.Flags().StringVarP(&editEnd, "end", "e", "", "New end time (inclusive date for all-day events)")
+	editCmd.Flags().DurationVar(&editDuration, "duration", 0, "New event duration, e.g. 30m, 1h30m")
+	editCmd.Flags().BoolVar(&editAllDay, "all-day", false, "Make the event an all-day event (--all-day=false for a timed event)")
+	editCmd.Flags().StringVar(&editLocation, "location", "", "New event location")
+	editCmd.Flags().StringVar(&editDescription, "description", "", "New event description")
+	editCmd.Flags().StringSliceVar(&editAttendees, "attendee", []string{}, "Attendee email address, replaces existing attendees (can be repeated)")
+	editCmd.Flags().StringVar(&editCalendar, "calendar", "", "Calendar ID the event belongs to (default: search calendar_id_list)")
+	editCmd.Flags().StringVar(&editScope, "scope", scopeThis, "Occurrences of a recurring event to edit: this, following, all")
+	editCmd.Flags().StringVarP(&editOutput, "output", "o", "table", "Output format: "+strings.Join(resultOutputFormats, ", "))
+}
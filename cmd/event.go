@@ -0,0 +1,206 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/longkey1/gcal/internal/dateexpr"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// Scopes for changing occurrences of a recurring event
+const (
+	scopeThis      = "this"
+	scopeFollowing = "following"
+	scopeAll       = "all"
+)
+
+func validateRecurrenceScope(scope string) error {
+	validScopes := map[string]bool{scopeThis: true, scopeFollowing: true, scopeAll: true}
+	if !validScopes[scope] {
+		return fmt.Errorf("invalid scope: %s (valid: this, following, all)", scope)
+	}
+	return nil
+}
+
+// recurrenceScope returns the occurrences to change for event given --scope.
+// A single event is only itself. A recurring event given by the ID of its series, rather than of
+// an occurrence, can only be changed as a whole, which must be asked for with --scope all.
+func recurrenceScope(event *calendar.Event, scope string) (string, error) {
+	switch {
+	case len(event.Recurrence) > 0:
+		if scope != scopeAll {
+			return "", fmt.Errorf("%s is a recurring event: use --scope all to change the whole series, or the ID of an occurrence to change only some occurrences", event.Id)
+		}
+		return scopeAll, nil
+	case event.RecurringEventId == "":
+		return scopeThis, nil
+	default:
+		return scope, nil
+	}
+}
+
+// seriesID returns the ID of the recurring event that event is an occurrence of, or is itself
+func seriesID(event *calendar.Event) string {
+	if event.RecurringEventId != "" {
+		return event.RecurringEventId
+	}
+	return event.Id
+}
+
+// parseEventTime parses a date or date-time given on the command line.
+// Besides the formats understood by dateexpr, a date expression followed by a time
+// of day such as "tomorrow 10:00" is accepted.
+// The returned bool reports whether the value was a date without a time of day.
+func parseEventTime(s string) (time.Time, bool, error) {
+	if r, err := dateexpr.Parse(s); err == nil {
+		if r.IsInstant() {
+			return r.Start, false, nil
+		}
+		if r.IsDay() {
+			return r.Start, true, nil
+		}
+	}
+
+	if expr, clock, ok := cutLast(strings.TrimSpace(s), " "); ok {
+		if tod, err := time.Parse("15:04", clock); err == nil {
+			if r, err := dateexpr.Parse(expr); err == nil && r.IsDay() {
+				d := r.Start
+				return time.Date(d.Year(), d.Month(), d.Day(), tod.Hour(), tod.Minute(), 0, 0, d.Location()), false, nil
+			}
+		}
+	}
+
+	return time.Time{}, false, fmt.Errorf("invalid time %q (expected a date such as YYYY-MM-DD or tomorrow, optionally followed by HH:MM, or RFC3339)", s)
+}
+
+// cutLast slices s around the last instance of sep
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+// newEventDateTime builds an EventDateTime, using a date value for all-day events
+func newEventDateTime(t time.Time, allDay bool) *calendar.EventDateTime {
+	if allDay {
+		return &calendar.EventDateTime{Date: t.Format("2006-01-02")}
+	}
+	return &calendar.EventDateTime{DateTime: t.Format(time.RFC3339)}
+}
+
+// patchEventDateTime builds an EventDateTime for a patch request, clearing the value of the other kind
+// so that events can switch between timed and all-day
+func patchEventDateTime(t time.Time, allDay bool) *calendar.EventDateTime {
+	dt := newEventDateTime(t, allDay)
+	if allDay {
+		dt.NullFields = []string{"DateTime", "TimeZone"}
+	} else {
+		dt.NullFields = []string{"Date"}
+	}
+	return dt
+}
+
+// eventDateTimeValue parses an EventDateTime.
+// The returned bool reports whether it is an all-day date.
+func eventDateTimeValue(t *calendar.EventDateTime) (time.Time, bool, error) {
+	if t == nil {
+		return time.Time{}, false, fmt.Errorf("missing event time")
+	}
+	if t.DateTime != "" {
+		parsed, err := time.Parse(time.RFC3339, t.DateTime)
+		return parsed, false, err
+	}
+	parsed, err := time.ParseInLocation("2006-01-02", t.Date, time.Now().Location())
+	return parsed, true, err
+}
+
+// shiftEventDateTime returns a copy of t moved by d, keeping its kind and time zone
+func shiftEventDateTime(t *calendar.EventDateTime, d time.Duration) (*calendar.EventDateTime, error) {
+	value, allDay, err := eventDateTimeValue(t)
+	if err != nil {
+		return nil, err
+	}
+	shifted := newEventDateTime(value.Add(d), allDay)
+	if allDay {
+		// Adding whole days keeps the date stable across DST changes
+		shifted.Date = value.AddDate(0, 0, int(d.Round(24*time.Hour)/(24*time.Hour))).Format("2006-01-02")
+	}
+	shifted.TimeZone = t.TimeZone
+	return shifted, nil
+}
+
+// formatEventDateTime formats an event time with its date, for use in messages
+func formatEventDateTime(t *calendar.EventDateTime) string {
+	value, allDay, err := eventDateTimeValue(t)
+	if err != nil {
+		return ""
+	}
+	if allDay {
+		return value.Format("2006-01-02") + " (all-day)"
+	}
+	return value.Local().Format("2006-01-02 15:04")
+}
+
+// newAttendees converts email addresses to event attendees
+func newAttendees(emails []string) []*calendar.EventAttendee {
+	attendees := make([]*calendar.EventAttendee, 0, len(emails))
+	for _, email := range emails {
+		email = strings.TrimSpace(email)
+		if email == "" {
+			continue
+		}
+		attendees = append(attendees, &calendar.EventAttendee{Email: email})
+	}
+	return attendees
+}
+
+// wrapWriteError adds a hint to re-authenticate when the token lacks write access
+func wrapWriteError(err error) error {
+	if isInsufficientScope(err) {
+		return fmt.Errorf("%w (run 'gcal auth --write' to grant write access)", err)
+	}
+	return err
+}
+
+// wrapManageError adds a hint to re-authenticate when the token lacks access to manage calendars
+func wrapManageError(err error) error {
+	if isInsufficientScope(err) {
+		return fmt.Errorf("%w (run 'gcal auth --manage' to grant access to manage calendars)", err)
+	}
+	return err
+}
+
+// isInsufficientScope reports whether err was caused by a token without the required OAuth scope
+func isInsufficientScope(err error) bool {
+	var gerr *googleapi.Error
+	if !errors.As(err, &gerr) || gerr.Code != http.StatusForbidden {
+		return false
+	}
+	for _, item := range gerr.Errors {
+		if item.Reason == "insufficientPermissions" {
+			return true
+		}
+	}
+	return strings.Contains(strings.ToLower(gerr.Message), "insufficient authentication scopes")
+}
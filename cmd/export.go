@@ -0,0 +1,152 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/longkey1/gcal/internal/gcal"
+	"github.com/longkey1/gcal/internal/ical"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportSince    string
+	exportTo       string
+	exportCalendar string
+	exportFile     string
+	exportExpand   bool
+	exportPartial  bool
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export events as an iCalendar (.ics) file",
+	Long: `Export events from the configured calendars as iCalendar (RFC 5545) data.
+Recurring events are exported as series with their recurrence rules, so other
+calendar applications can import them unchanged. Use --expand to export every
+occurrence as a separate event instead.
+Without --since and --to all events of the calendars are exported.`,
+	Example: `  # Export all calendars to a file
+  gcal export --file calendar.ics
+
+  # Export one calendar for this year to stdout
+  gcal export --calendar work@example.com --since "this year" --to "this year"`,
+	Args: cobra.NoArgs,
+	RunE: runExport,
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	svc, err := gcal.NewService(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("unable to create gcal service: %w", err)
+	}
+
+	parser, err := newDateParser(cfg)
+	if err != nil {
+		return err
+	}
+	opts := gcal.ListOptions{Partial: exportPartial, Recurring: !exportExpand}
+	if exportSince != "" {
+		since, err := parser.Parse(exportSince)
+		if err != nil {
+			return fmt.Errorf("invalid since date: %w", err)
+		}
+		opts.TimeMin = since.Start.Format(time.RFC3339)
+	}
+	if exportTo != "" {
+		to, err := parser.Parse(exportTo)
+		if err != nil {
+			return fmt.Errorf("invalid to date: %w", err)
+		}
+		opts.TimeMax = to.End.Format(time.RFC3339)
+	}
+
+	var name string
+	if exportCalendar != "" {
+		cid, err := svc.ResolveCalendarID(exportCalendar)
+		if err != nil {
+			return err
+		}
+		svc.CalendarIDList = []string{cid}
+		name = cid
+		if c, err := svc.Calendar.Calendars.Get(cid).Context(ctx).Do(); err == nil && c.Summary != "" {
+			name = c.Summary
+		}
+	}
+
+	result, err := svc.ListEvents(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("unable to retrieve events: %w", err)
+	}
+	reportCalendarErrors(result.Errors)
+	events := result.Events
+	if exportExpand {
+		sortEvents(events, "start")
+	}
+
+	var w io.Writer = os.Stdout
+	var f *os.File
+	if exportFile != "" {
+		f, err = os.Create(exportFile)
+		if err != nil {
+			return fmt.Errorf("unable to create export file: %w", err)
+		}
+		w = f
+	}
+
+	enc := ical.NewEncoder(w)
+	enc.Name = name
+	enc.Expanded = exportExpand
+	if err := enc.Encode(events); err != nil {
+		// A partially written file is not a valid calendar
+		if f != nil {
+			f.Close()
+			os.Remove(exportFile)
+		}
+		return fmt.Errorf("unable to export events: %w", err)
+	}
+	if f == nil {
+		return nil
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(exportFile)
+		return fmt.Errorf("unable to write export file: %w", err)
+	}
+
+	fmt.Printf("Events exported to %s\n", exportFile)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.Flags().StringVarP(&exportSince, "since", "s", "", "Export events from this date (YYYY-MM-DD, today, -1y, ...)")
+	exportCmd.Flags().StringVarP(&exportTo, "to", "t", "", "Export events until this date (YYYY-MM-DD, next month, ...)")
+	exportCmd.Flags().StringVar(&exportCalendar, "calendar", "", "Export only this calendar from calendar_id_list")
+	exportCmd.Flags().StringVarP(&exportFile, "file", "f", "", "Write to this file instead of stdout")
+	exportCmd.Flags().BoolVar(&exportExpand, "expand", false, "Export each occurrence of recurring events as a separate event")
+	exportCmd.Flags().BoolVar(&exportPartial, "partial", false, "Export the calendars that succeeded when others fail")
+}
@@ -0,0 +1,253 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/longkey1/gcal/internal/gcal"
+	"github.com/spf13/cobra"
+)
+
+var (
+	freeSince     string
+	freeTo        string
+	freeDuration  time.Duration
+	freeWorkHours string
+	freeWorkDays  []string
+	freeBuffer    time.Duration
+	freeTimeZone  string
+	freeAttendees []string
+	freeOutput    string
+)
+
+var freeCmd = &cobra.Command{
+	Use:   "free",
+	Short: "Find free time slots",
+	Long: `Find the time slots in which all calendars are free.
+The busy times of every calendar in calendar_id_list, or of the people given with
+--attendee, are combined and the gaps within working hours that are at least
+--duration long are listed.
+--to is resolved relative to --since, so "--since monday --to friday" is the
+Monday to Friday of one week: the coming week, or this week on a Monday.
+Working hours, working days, the minimum slot length, the buffer kept around busy
+times and the time zone default to the [free] section of the config file.`,
+	Example: `  # Find 30 minute slots in the rest of this week
+  gcal free --duration 30m --since "this week"
+
+  # Find 30 minute slots from the next Monday to the Friday after it
+  gcal free --duration 30m --since monday --to friday
+
+  # Find one hour slots with teammates tomorrow, keeping 10 minutes around meetings
+  gcal free --duration 1h --since tomorrow --attendee alice@example.com --attendee bob@example.com --buffer 10m
+
+  # Search in another time zone and working hours
+  gcal free --since "next week" --tz America/New_York --work-hours 10:00-16:00`,
+	Args:    cobra.NoArgs,
+	PreRunE: validateFreeFlags,
+	RunE:    runFree,
+}
+
+func validateFreeFlags(cmd *cobra.Command, args []string) error {
+	if cmd.Flags().Lookup("duration").Changed && freeDuration <= 0 {
+		return fmt.Errorf("--duration must be positive")
+	}
+	if freeBuffer < 0 {
+		return fmt.Errorf("--buffer must not be negative")
+	}
+	if freeOutput != "table" && freeOutput != "json" {
+		return fmt.Errorf("invalid output format: %s (valid: table, json)", freeOutput)
+	}
+	return nil
+}
+
+func runFree(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	opts, err := freeSlotOptions(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	parser, err := newDateParser(cfg)
+	if err != nil {
+		return err
+	}
+	since, err := parser.Parse(freeSince)
+	if err != nil {
+		return fmt.Errorf("invalid since date: %w", err)
+	}
+	to := since
+	if freeTo != "" {
+		// Relative to --since, so that a weekday is the one on or after it
+		toParser := *parser
+		toParser.Now = func() time.Time { return since.Start }
+		to, err = toParser.Parse(freeTo)
+		if err != nil {
+			return fmt.Errorf("invalid to date: %w", err)
+		}
+		if !to.End.After(since.Start) {
+			return fmt.Errorf("--to is before --since")
+		}
+	}
+	// Date expressions are read as wall clock times of the search time zone
+	from, until := wallClockIn(since.Start, opts.Location), wallClockIn(to.End, opts.Location)
+	if now := time.Now(); from.Before(now) {
+		from = now.Truncate(time.Minute)
+	}
+	if !until.After(from) {
+		return fmt.Errorf("the time range is in the past or empty")
+	}
+
+	ctx := context.Background()
+	svc, err := gcal.NewService(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("unable to create gcal service: %w", err)
+	}
+
+	ids := svc.CalendarIDList
+	if len(freeAttendees) > 0 {
+		ids = freeAttendees
+	}
+	busy, err := svc.FreeBusy(ctx, ids, from, until)
+	if err != nil {
+		return fmt.Errorf("unable to retrieve free/busy information: %w", err)
+	}
+	var all []gcal.Period
+	for _, periods := range busy {
+		all = append(all, periods...)
+	}
+
+	slots := gcal.FreeSlots(all, from, until, opts)
+	if freeOutput == "json" {
+		return outputFreeJSON(os.Stdout, slots, opts.Location)
+	}
+	return outputFreeTable(os.Stdout, slots, opts.Location)
+}
+
+// freeSlotOptions combines the flags with the [free] config section
+func freeSlotOptions(cmd *cobra.Command, cfg *gcal.Config) (gcal.SlotOptions, error) {
+	workHours := cfg.Free.WorkHours
+	if freeWorkHours != "" {
+		workHours = freeWorkHours
+	}
+	workDays := cfg.Free.WorkDays
+	if len(freeWorkDays) > 0 {
+		workDays = freeWorkDays
+	}
+	tz := cfg.Free.TimeZone
+	if freeTimeZone != "" {
+		tz = freeTimeZone
+	}
+
+	opts := gcal.SlotOptions{
+		MinDuration: cfg.Free.MinDuration,
+		Buffer:      cfg.Free.Buffer,
+		Location:    time.Local,
+	}
+	if cmd.Flags().Lookup("duration").Changed {
+		opts.MinDuration = freeDuration
+	}
+	if cmd.Flags().Lookup("buffer").Changed {
+		opts.Buffer = freeBuffer
+	}
+
+	var err error
+	opts.WorkStart, opts.WorkEnd, err = gcal.ParseWorkHours(workHours)
+	if err != nil {
+		return opts, err
+	}
+	opts.WorkDays, err = gcal.ParseWorkDays(workDays)
+	if err != nil {
+		return opts, fmt.Errorf("invalid working days: %w", err)
+	}
+	if tz != "" {
+		opts.Location, err = time.LoadLocation(tz)
+		if err != nil {
+			return opts, fmt.Errorf("invalid time zone: %w", err)
+		}
+	}
+	return opts, nil
+}
+
+// wallClockIn returns the time with the same wall clock as t in loc
+func wallClockIn(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+}
+
+func outputFreeTable(w io.Writer, slots []gcal.Period, loc *time.Location) error {
+	if len(slots) == 0 {
+		fmt.Fprintln(w, "No free slots found.")
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "DATE\tSTART\tEND\tDURATION")
+	for _, s := range slots {
+		start, end := s.Start.In(loc), s.End.In(loc)
+		endText := end.Format("15:04")
+		if end.YearDay() != start.YearDay() && end.Hour() == 0 && end.Minute() == 0 {
+			endText = "24:00"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", start.Format("2006-01-02 Mon"), start.Format("15:04"), endText, formatDuration(s.Duration()))
+	}
+	return tw.Flush()
+}
+
+func outputFreeJSON(w io.Writer, slots []gcal.Period, loc *time.Location) error {
+	type slot struct {
+		Start    time.Time `json:"start"`
+		End      time.Time `json:"end"`
+		Duration string    `json:"duration"`
+		Minutes  int       `json:"minutes"`
+	}
+	out := make([]slot, 0, len(slots))
+	for _, s := range slots {
+		out = append(out, slot{
+			Start:    s.Start.In(loc),
+			End:      s.End.In(loc),
+			Duration: formatDuration(s.Duration()),
+			Minutes:  int(s.Duration().Minutes()),
+		})
+	}
+	b, err := json.Marshal(out)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "%s", b)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(freeCmd)
+	freeCmd.Flags().StringVarP(&freeSince, "since", "s", "today", "Start of the search (YYYY-MM-DD, today, monday, this week, ...)")
+	freeCmd.Flags().StringVarP(&freeTo, "to", "t", "", "End of the search, relative to --since (default: end of --since)")
+	freeCmd.Flags().DurationVar(&freeDuration, "duration", 0, "Minimum slot length (default from free.min_duration config, 30m)")
+	freeCmd.Flags().StringVar(&freeWorkHours, "work-hours", "", "Working hours as HH:MM-HH:MM (default from free.work_hours config, 09:00-18:00)")
+	freeCmd.Flags().StringSliceVar(&freeWorkDays, "work-days", []string{}, "Working days, e.g. mon-fri or mon,wed (default from free.work_days config, mon-fri)")
+	freeCmd.Flags().DurationVar(&freeBuffer, "buffer", 0, "Time kept free before and after busy times (default from free.buffer config)")
+	freeCmd.Flags().StringVar(&freeTimeZone, "tz", "", "Time zone of the working hours and output (default from free.time_zone config, local)")
+	freeCmd.Flags().StringSliceVar(&freeAttendees, "attendee", []string{}, "Email address to check instead of calendar_id_list (can be repeated)")
+	freeCmd.Flags().StringVarP(&freeOutput, "output", "o", "table", "Output format: table, json")
+}
@@ -0,0 +1,250 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/longkey1/gcal/internal/gcal"
+	"github.com/longkey1/gcal/internal/ical"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/calendar/v3"
+)
+
+var (
+	importCalendar string
+	importDryRun   bool
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import events from an iCalendar (.ics) file",
+	Long: `Import the events of an iCalendar (RFC 5545) file into one of the configured calendars.
+Events keep their iCalendar UID, so importing the same file again updates the
+events instead of creating duplicates. Recurring events keep their recurrence
+rules, and modified or cancelled occurrences are applied to the imported series.
+Use - as the file to read from stdin.
+Importing requires write access; run 'gcal auth --write' first when using OAuth.`,
+	Example: `  # Show what would be imported
+  gcal import schedule.ics --dry-run
+
+  # Import into a specific calendar
+  gcal import schedule.ics --calendar team@group.calendar.google.com`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImport,
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	events, err := readICS(args[0])
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	var svc *gcal.Service
+	if importDryRun {
+		svc, err = gcal.NewService(ctx, cfg)
+	} else {
+		svc, err = gcal.NewWriteService(ctx, cfg)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to create gcal service: %w", err)
+	}
+
+	cid, err := svc.ResolveCalendarID(importCalendar)
+	if err != nil {
+		return err
+	}
+
+	// Series and single events are imported first, then the occurrences overriding a series
+	var items, overrides []*calendar.Event
+	for _, e := range events {
+		if e.OriginalStartTime != nil {
+			overrides = append(overrides, e)
+		} else {
+			items = append(items, e)
+		}
+	}
+
+	// Checked before anything is written, so that the import does not stop halfway
+	if err := checkOverrides(items, overrides); err != nil {
+		return err
+	}
+
+	var created, updated []*calendar.Event
+	for _, e := range items {
+		found, err := svc.FindEventByICalUID(ctx, cid, e.ICalUID)
+		if err != nil {
+			return fmt.Errorf("unable to look up event %s: %w", e.ICalUID, err)
+		}
+		if found != nil {
+			updated = append(updated, e)
+		} else {
+			created = append(created, e)
+		}
+	}
+
+	if importDryRun {
+		return outputImportSummary(os.Stdout, created, updated, len(overrides), true)
+	}
+
+	imported := make(map[string]*calendar.Event)
+	for _, e := range items {
+		result, err := svc.Calendar.Events.Import(cid, e).Context(ctx).Do()
+		if err != nil {
+			return fmt.Errorf("unable to import event %s: %w", e.ICalUID, wrapWriteError(err))
+		}
+		imported[e.ICalUID] = result
+	}
+
+	for _, e := range overrides {
+		if err := importOverride(ctx, svc, cid, e, imported[e.ICalUID]); err != nil {
+			return fmt.Errorf("unable to import occurrence of %s: %w", e.ICalUID, wrapWriteError(err))
+		}
+	}
+
+	return outputImportSummary(os.Stdout, created, updated, len(overrides), false)
+}
+
+// readICS decodes the events of an iCalendar file, or of stdin if path is -
+func readICS(path string) ([]*calendar.Event, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open file: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	events, err := ical.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", path, err)
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("no events found in %s", path)
+	}
+	return events, nil
+}
+
+// checkOverrides checks that the recurring event of every overriding occurrence is in the file
+func checkOverrides(items, overrides []*calendar.Event) error {
+	series := make(map[string]bool)
+	for _, e := range items {
+		if len(e.Recurrence) > 0 {
+			series[e.ICalUID] = true
+		}
+	}
+	var missing []string
+	for _, e := range overrides {
+		if !series[e.ICalUID] && !slices.Contains(missing, e.ICalUID) {
+			missing = append(missing, e.ICalUID)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("occurrences of recurring events that are not part of the file: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// importOverride applies a modified or cancelled occurrence to the instance of its imported series
+func importOverride(ctx context.Context, svc *gcal.Service, cid string, e, series *calendar.Event) error {
+	if series == nil {
+		return fmt.Errorf("the recurring event is not part of the file")
+	}
+
+	originalStart := e.OriginalStartTime.DateTime
+	if originalStart == "" {
+		originalStart = e.OriginalStartTime.Date
+	}
+	instances, err := svc.Calendar.Events.Instances(cid, series.Id).
+		OriginalStart(originalStart).ShowDeleted(true).Context(ctx).Do()
+	if err != nil {
+		return err
+	}
+	if len(instances.Items) == 0 {
+		return fmt.Errorf("no occurrence starts at %s", originalStart)
+	}
+	instance := instances.Items[0]
+
+	if e.Status == "cancelled" {
+		if instance.Status == "cancelled" {
+			return nil
+		}
+		return svc.Calendar.Events.Delete(cid, instance.Id).Context(ctx).Do()
+	}
+	e.Id = instance.Id
+	e.RecurringEventId = series.Id
+	_, err = svc.Calendar.Events.Update(cid, instance.Id, e).Context(ctx).Do()
+	return err
+}
+
+// outputImportSummary prints a titled table for the created and the updated events,
+// followed by the number of overridden occurrences if there are any
+func outputImportSummary(w io.Writer, created, updated []*calendar.Event, overrides int, dryRun bool) error {
+	createdTitle, updatedTitle, appliedVerb := "Created", "Updated", "Applied"
+	if dryRun {
+		createdTitle, updatedTitle, appliedVerb = "Would create", "Would update", "Would apply"
+	}
+
+	columns, err := parseColumns([]string{"date", "start", "end", "title"})
+	if err != nil {
+		return err
+	}
+	opts := &outputOptions{columns: columns}
+
+	sections := 0
+	for _, section := range []struct {
+		title  string
+		events []*calendar.Event
+	}{{createdTitle, created}, {updatedTitle, updated}} {
+		if len(section.events) == 0 {
+			continue
+		}
+		if sections > 0 {
+			fmt.Fprintln(w)
+		}
+		sections++
+		fmt.Fprintf(w, "%s (%d):\n", section.title, len(section.events))
+		if err := outputTable(w, section.events, opts); err != nil {
+			return err
+		}
+	}
+	if overrides > 0 {
+		if sections > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s %d modified or cancelled occurrences of recurring events.\n", appliedVerb, overrides)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+	importCmd.Flags().StringVar(&importCalendar, "calendar", "", "Calendar ID to import into (default: first in calendar_id_list)")
+	importCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "Show the events that would be created or updated without importing")
+}
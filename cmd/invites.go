@@ -0,0 +1,229 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/longkey1/gcal/internal/gcal"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/calendar/v3"
+)
+
+var (
+	invitesSince       string
+	invitesTo          string
+	invitesOutput      string
+	invitesInteractive bool
+	invitesNotify      bool
+	invitesPartial     bool
+)
+
+var invitesCmd = &cobra.Command{
+	Use:   "invites",
+	Short: "List invitations awaiting a response",
+	Long: `List the events you have not responded to yet across all configured calendars.
+Recurring events are listed once, with their first pending occurrence.
+With --interactive each invitation is shown in turn and can be accepted,
+declined or tentatively accepted; responses to recurring events apply to
+every occurrence.`,
+	Example: `  # List pending invitations of the next 30 days
+  gcal invites
+
+  # Go through the invitations of the next week one by one
+  gcal invites --to +1w --interactive`,
+	Args:    cobra.NoArgs,
+	PreRunE: validateInvitesFlags,
+	RunE:    runInvites,
+}
+
+func validateInvitesFlags(cmd *cobra.Command, args []string) error {
+	if invitesInteractive && cmd.Flags().Changed("output") {
+		return fmt.Errorf("cannot use --output and --interactive together")
+	}
+	return validateOutputFormat(invitesOutput, resultOutputFormats)
+}
+
+func runInvites(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	parser, err := newDateParser(cfg)
+	if err != nil {
+		return err
+	}
+	since, err := parser.Parse(invitesSince)
+	if err != nil {
+		return fmt.Errorf("invalid since date: %w", err)
+	}
+	// Relative to --since, so that "--since next month --to +1w" is the first week of next month
+	toParser := *parser
+	toParser.Now = func() time.Time { return since.Start }
+	to, err := toParser.Parse(invitesTo)
+	if err != nil {
+		return fmt.Errorf("invalid to date: %w", err)
+	}
+	if !to.End.After(since.Start) {
+		return fmt.Errorf("--to is before --since")
+	}
+
+	ctx := context.Background()
+	newService := gcal.NewService
+	if invitesInteractive {
+		newService = gcal.NewWriteService
+	}
+	svc, err := newService(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("unable to create gcal service: %w", err)
+	}
+
+	result, err := svc.ListEvents(ctx, gcal.ListOptions{
+		TimeMin: since.Start.Format(time.RFC3339),
+		TimeMax: to.End.Format(time.RFC3339),
+		Partial: invitesPartial,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to retrieve events: %w", err)
+	}
+	reportCalendarErrors(result.Errors)
+
+	invites := pendingInvites(result.Events)
+	sortEvents(invites, "start")
+
+	if invitesInteractive {
+		return triageInvites(ctx, svc, invites, result.CalendarIDs)
+	}
+
+	opts := newOutputOptions(result.CalendarIDs)
+	opts.columns, _ = parseColumns([]string{"date", "start", "end", "title", "organizer", "calendar", "id"})
+	if err := outputEvents(os.Stdout, invites, invitesOutput, opts); err != nil {
+		return fmt.Errorf("unable to output events: %w", err)
+	}
+	return nil
+}
+
+// pendingInvites returns the events awaiting a response, keeping only the first occurrence of each recurring event
+func pendingInvites(events []*calendar.Event) []*calendar.Event {
+	seen := make(map[string]bool)
+	invites := make([]*calendar.Event, 0)
+	for _, e := range events {
+		self := gcal.SelfAttendee(e)
+		if self == nil || self.Organizer || self.ResponseStatus != gcal.ResponseNeedsAction || e.Status == "cancelled" {
+			continue
+		}
+		if e.RecurringEventId != "" {
+			if seen[e.RecurringEventId] {
+				continue
+			}
+			seen[e.RecurringEventId] = true
+		}
+		invites = append(invites, e)
+	}
+	return invites
+}
+
+// triageInvites asks for a response to each invitation in turn
+func triageInvites(ctx context.Context, svc *gcal.Service, invites []*calendar.Event, calendars map[*calendar.Event]string) error {
+	if len(invites) == 0 {
+		fmt.Println("No pending invitations.")
+		return nil
+	}
+
+	in := bufio.NewReader(os.Stdin)
+	for i, e := range invites {
+		fmt.Printf("\n[%d/%d] %s\n", i+1, len(invites), e.Summary)
+		fmt.Printf("  When:      %s\n", formatEventSpan(e))
+		if e.RecurringEventId != "" {
+			fmt.Println("  Repeats:   response applies to all occurrences")
+		}
+		if e.Organizer != nil {
+			fmt.Printf("  Organizer: %s\n", formatPerson(e.Organizer.DisplayName, e.Organizer.Email))
+		}
+		if e.Location != "" {
+			fmt.Printf("  Location:  %s\n", e.Location)
+		}
+
+		status, quit, err := promptResponse(in)
+		if err != nil {
+			return err
+		}
+		if quit {
+			return nil
+		}
+		if status == "" {
+			continue
+		}
+
+		target := e
+		if e.RecurringEventId != "" {
+			// Respond to the series rather than this occurrence
+			target, err = svc.Calendar.Events.Get(calendars[e], e.RecurringEventId).Context(ctx).Do()
+			if err != nil {
+				return fmt.Errorf("unable to retrieve recurring event: %w", err)
+			}
+		}
+		if _, err := svc.Respond(ctx, calendars[e], target, status, "", invitesNotify); err != nil {
+			return fmt.Errorf("unable to respond to event: %w", wrapWriteError(err))
+		}
+		fmt.Printf("Responded %s.\n", status)
+	}
+	return nil
+}
+
+// promptResponse reads a response from in. An empty status means the invitation is skipped.
+func promptResponse(in *bufio.Reader) (status string, quit bool, err error) {
+	for {
+		fmt.Print("[a]ccept, [d]ecline, [t]entative, [s]kip, [q]uit: ")
+		line, err := in.ReadString('\n')
+		if err == io.EOF && line == "" {
+			fmt.Println()
+			return "", true, nil
+		}
+		if err != nil && err != io.EOF {
+			return "", false, err
+		}
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "a", "accept":
+			return gcal.ResponseAccepted, false, nil
+		case "d", "decline":
+			return gcal.ResponseDeclined, false, nil
+		case "t", "tentative":
+			return gcal.ResponseTentative, false, nil
+		case "s", "skip", "":
+			return "", false, nil
+		case "q", "quit":
+			return "", true, nil
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(invitesCmd)
+	invitesCmd.Flags().StringVarP(&invitesSince, "since", "s", "today", "Start of the period to look for invitations (YYYY-MM-DD, today, ...)")
+	invitesCmd.Flags().StringVarP(&invitesTo, "to", "t", "+30d", "End of the period to look for invitations, relative to --since (YYYY-MM-DD, +2w, next month, ...)")
+	invitesCmd.Flags().StringVarP(&invitesOutput, "output", "o", "table", "Output format: "+strings.Join(resultOutputFormats, ", "))
+	invitesCmd.Flags().BoolVarP(&invitesInteractive, "interactive", "i", false, "Respond to each invitation in turn")
+	invitesCmd.Flags().BoolVar(&invitesNotify, "notify", false, "Notify organizers of the responses given with --interactive")
+	invitesCmd.Flags().BoolVar(&invitesPartial, "partial", false, "Use the calendars that succeeded when others fail")
+}
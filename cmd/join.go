@@ -0,0 +1,165 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"regexp"
+	"time"
+
+	"github.com/longkey1/gcal/internal/gcal"
+	"github.com/longkey1/gcal/internal/google"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/calendar/v3"
+)
+
+var (
+	joinCalendar string
+	joinWithin   time.Duration
+	joinPrint    bool
+	joinPartial  bool
+)
+
+var joinCmd = &cobra.Command{
+	Use:   "join [event-id]",
+	Short: "Open the video conference of a meeting",
+	Long: `Open the video conference link of a meeting in the browser.
+Without an event ID, the meeting in progress or the next meeting starting within
+--within that has a conference link is used. The link is taken from the Google
+Meet conference data of the event, or from a Zoom, Microsoft Teams, Google Meet
+or Webex URL in its location or description.`,
+	Example: `  # Join the current or next meeting
+  gcal join
+
+  # Print the link instead of opening it
+  gcal join --print
+
+  # Join a specific meeting
+  gcal join abc123`,
+	Args:    cobra.MaximumNArgs(1),
+	PreRunE: validateJoinFlags,
+	RunE:    runJoin,
+}
+
+func validateJoinFlags(cmd *cobra.Command, args []string) error {
+	if joinWithin <= 0 {
+		return fmt.Errorf("--within must be positive")
+	}
+	return nil
+}
+
+func runJoin(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	svc, err := gcal.NewService(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("unable to create gcal service: %w", err)
+	}
+
+	var event *calendar.Event
+	if len(args) == 1 {
+		_, event, err = svc.FindEvent(ctx, joinCalendar, args[0])
+		if err != nil {
+			return fmt.Errorf("unable to find event: %w", err)
+		}
+	} else {
+		event, err = findNextMeeting(ctx, svc)
+		if err != nil {
+			return err
+		}
+	}
+
+	url := joinURL(event)
+	if url == "" {
+		return fmt.Errorf("event %q has no conference link", event.Summary)
+	}
+
+	if joinPrint {
+		fmt.Println(url)
+		return nil
+	}
+	fmt.Printf("Joining %s: %s\n", event.Summary, url)
+	if err := google.OpenBrowser(url); err != nil {
+		return fmt.Errorf("unable to open browser: %w", err)
+	}
+	return nil
+}
+
+// findNextMeeting returns the ongoing or next event within --within that has a conference link
+func findNextMeeting(ctx context.Context, svc *gcal.Service) (*calendar.Event, error) {
+	now := time.Now()
+	result, err := svc.ListEvents(ctx, gcal.ListOptions{
+		TimeMin: now.Format(time.RFC3339),
+		TimeMax: now.Add(joinWithin).Format(time.RFC3339),
+		Partial: joinPartial,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve events: %w", err)
+	}
+	reportCalendarErrors(result.Errors)
+
+	meetings := make([]*calendar.Event, 0, len(result.Events))
+	for _, e := range result.Events {
+		if joinURL(e) != "" {
+			meetings = append(meetings, e)
+		}
+	}
+	next, ok := findNextEvent(meetings, now, false)
+	if !ok {
+		return nil, fmt.Errorf("no meeting with a conference link within %s", formatDuration(joinWithin))
+	}
+	return next.event, nil
+}
+
+// meetingURLPatterns match the join links of common video conference services
+var meetingURLPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`https://meet\.google\.com/[a-z]{3}-[a-z]{4}-[a-z]{3}`),
+	regexp.MustCompile(`https://[\w.-]*zoom\.us/(?:j|my|w|s)/[^\s"'<>]+`),
+	regexp.MustCompile(`https://teams\.microsoft\.com/l/meetup-join/[^\s"'<>]+`),
+	regexp.MustCompile(`https://teams\.live\.com/meet/[^\s"'<>]+`),
+	regexp.MustCompile(`https://[\w.-]+\.webex\.com/[^\s"'<>]+`),
+}
+
+// joinURL returns the link to join the video conference of an event, if any.
+// The conference data of the event is preferred over URLs in its location and description.
+func joinURL(e *calendar.Event) string {
+	if link := conferenceLink(e); link != "" {
+		return link
+	}
+	for _, text := range []string{e.Location, e.Description} {
+		for _, re := range meetingURLPatterns {
+			if m := re.FindString(text); m != "" {
+				// Descriptions are often HTML, where & is written as &amp;
+				return html.UnescapeString(m)
+			}
+		}
+	}
+	return ""
+}
+
+func init() {
+	rootCmd.AddCommand(joinCmd)
+	joinCmd.Flags().StringVar(&joinCalendar, "calendar", "", "Calendar the event belongs to (default: search calendar_id_list)")
+	joinCmd.Flags().DurationVar(&joinWithin, "within", 24*time.Hour, "Without an event ID, only consider meetings starting within this duration")
+	joinCmd.Flags().BoolVarP(&joinPrint, "print", "p", false, "Print the link instead of opening it")
+	joinCmd.Flags().BoolVar(&joinPartial, "partial", false, "Use the calendars that succeeded when others fail")
+}
@@ -22,9 +22,11 @@ import (
 	"io"
 	"os"
 	"sort"
+	"strings"
 	"text/tabwriter"
 	"time"
 
+	"github.com/longkey1/gcal/internal/dateexpr"
 	"github.com/longkey1/gcal/internal/gcal"
 	"github.com/spf13/cobra"
 	"google.golang.org/api/calendar/v3"
@@ -38,6 +40,16 @@ var (
 	listOutput          string
 	listSort            string
 	listIncludeDeclined bool
+	listPartial         bool
+	listWeek            bool
+	listMonth           bool
+	listDays            int
+	listWeekStart       string
+	listColumns         []string
+	listNoHeader        bool
+	listTemplate        string
+	listTemplateFile    string
+	listTemplateAll     bool
 )
 
 var listCmd = &cobra.Command{
@@ -56,14 +68,52 @@ Events can be filtered, sorted, and displayed in different formats.`,
   # List events in a date range
   gcal list --since 2024-01-01 --to 2024-01-31
 
+  # List tomorrow's events
+  gcal list --date tomorrow
+
+  # List events of this week, or of the next two weeks
+  gcal list --date "this week"
+  gcal list --since today --to +2w
+
   # List events in JSON format
   gcal list --output json
 
+  # List events as CSV for spreadsheets, or as a Markdown table
+  gcal list --output csv
+  gcal list --output markdown
+
+  # Save this week's events as an iCalendar file
+  gcal list --date "this week" --output ics > week.ics
+
   # List events sorted by last update
   gcal list --sort updated
 
   # Include declined events
-  gcal list --include-declined`,
+  gcal list --include-declined
+
+  # Show this week grouped by day
+  gcal list --week
+
+  # Show next month grouped by day
+  gcal list --month --date "next month"
+
+  # Show the next 10 days grouped by day, starting today
+  gcal list --days 10
+
+  # Render each event through a Go template
+  gcal list --output template --template '{{date "15:04" .Start}} {{.Summary}}'
+
+  # Render all events at once, e.g. for a status bar
+  gcal list -o template --template-all --template '{{.Count}} events'
+
+  # Choose table columns, truncating the title to 30 characters
+  gcal list --columns date,start,duration,title:30,location
+
+  # Print rows without a header for scripting
+  gcal list --columns id,title --no-header
+
+  # Show events from the calendars that could be fetched even if others fail
+  gcal list --partial`,
 	Args:   cobra.NoArgs,
 	PreRunE: validateListFlags,
 	RunE:   runList,
@@ -84,10 +134,30 @@ func validateListFlags(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("--to can only be used with --since")
 	}
 
-	// Validate output format
-	validOutputs := map[string]bool{"table": true, "json": true}
-	if !validOutputs[listOutput] {
-		return fmt.Errorf("invalid output format: %s (valid: table, json)", listOutput)
+	// Validate view modes
+	views := 0
+	for _, name := range []string{"week", "month", "days"} {
+		if cmd.Flags().Lookup(name).Changed {
+			views++
+		}
+	}
+	if views > 1 {
+		return fmt.Errorf("cannot use --week, --month and --days together")
+	}
+	if views > 0 && sinceFlag.Changed {
+		return fmt.Errorf("cannot use --week, --month or --days with --since")
+	}
+	if cmd.Flags().Lookup("days").Changed && listDays < 1 {
+		return fmt.Errorf("--days must be at least 1")
+	}
+
+	if err := validateOutputFormat(listOutput, outputFormats); err != nil {
+		return err
+	}
+
+	// Validate template options
+	if err := validateTemplateFlags(cmd, listOutput); err != nil {
+		return err
 	}
 
 	// Validate sort option
@@ -111,84 +181,176 @@ func runList(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("unable to create gcal service: %w", err)
 	}
 
-	var events []*calendar.Event
+	if listWeekStart != "" {
+		cfg.WeekStart = listWeekStart
+	}
 
-	if listSince != "" {
-		events, err = fetchRangeEvents(svc)
-	} else {
-		events, err = fetchDayEvents(svc)
+	columnSpecs := defaultColumns
+	if cmd.Flags().Lookup("columns").Changed {
+		columnSpecs = listColumns
+	} else if len(cfg.List.Columns) > 0 {
+		columnSpecs = cfg.List.Columns
 	}
+	columns, err := parseColumns(columnSpecs)
 	if err != nil {
-		return fmt.Errorf("unable to retrieve events: %w", err)
+		return err
 	}
 
-	if !listIncludeDeclined {
-		events = filterDeclinedEvents(events)
+	parser, err := newDateParser(cfg)
+	if err != nil {
+		return err
 	}
 
+	var result *gcal.ListResult
+	var view *dateexpr.Range
+
+	switch {
+	case listSince != "":
+		result, err = fetchRangeEvents(svc, parser)
+	case listWeek || listMonth || listDays > 0:
+		var r dateexpr.Range
+		r, err = viewRange(parser)
+		if err != nil {
+			return err
+		}
+		view = &r
+		result, err = fetchEvents(svc, r.Start.Format(time.RFC3339), r.End.Format(time.RFC3339))
+	default:
+		result, err = fetchDayEvents(svc, parser)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to retrieve events: %w", err)
+	}
+	events, truncated := result.Events, result.Truncated
+
 	sortEvents(events, listSort)
 
-	if err := outputEvents(os.Stdout, events, listOutput); err != nil {
+	// --max-results is a cap on the whole result after filtering and sorting, not on each calendar
+	if listMaxResults > 0 && int64(len(events)) > listMaxResults {
+		events = events[:listMaxResults]
+		truncated = true
+	}
+
+	opts := &outputOptions{calendars: result.CalendarIDs, columns: columns, noHeader: listNoHeader, templateAll: listTemplateAll}
+	if listOutput == "template" {
+		opts.template, err = parseOutputTemplate(listTemplate, listTemplateFile)
+		if err != nil {
+			return err
+		}
+	}
+	if view != nil && listOutput == "table" {
+		err = outputDayTable(os.Stdout, events, *view, opts)
+	} else {
+		err = outputEvents(os.Stdout, events, listOutput, opts)
+	}
+	if err != nil {
 		return fmt.Errorf("unable to output events: %w", err)
 	}
 
+	if truncated && listOutput == "table" {
+		fmt.Fprintf(os.Stderr, "\n(showing the first %d events; more are available, raise --max-results to see them)\n", len(events))
+	}
+
 	return nil
 }
 
-func fetchDayEvents(svc *gcal.Service) ([]*calendar.Event, error) {
-	targetDate, err := time.ParseInLocation("2006-01-02", listDate, time.Now().Location())
+func fetchDayEvents(svc *gcal.Service, p *dateexpr.Parser) (*gcal.ListResult, error) {
+	r, err := p.Parse(listDate)
 	if err != nil {
-		return nil, fmt.Errorf("invalid date format (expected YYYY-MM-DD): %w", err)
+		return nil, fmt.Errorf("invalid date: %w", err)
 	}
-
-	tmin := time.Date(targetDate.Year(), targetDate.Month(), targetDate.Day(), 0, 0, 0, 0, targetDate.Location()).Format(time.RFC3339)
-	tmax := time.Date(targetDate.Year(), targetDate.Month(), targetDate.Day(), 23, 59, 59, 59, targetDate.Location()).Format(time.RFC3339)
-
-	events := make([]*calendar.Event, 0)
-	for _, cid := range svc.CalendarIDList {
-		result, err := svc.Calendar.Events.List(cid).ShowDeleted(false).
-			SingleEvents(true).TimeMin(tmin).TimeMax(tmax).OrderBy("startTime").Do()
-		if err != nil {
-			return nil, err
-		}
-		events = append(events, result.Items...)
+	if r.IsInstant() {
+		r = dateexpr.Day(r.Start)
 	}
-	return events, nil
+
+	return fetchEvents(svc, r.Start.Format(time.RFC3339), r.End.Format(time.RFC3339))
 }
 
-func fetchRangeEvents(svc *gcal.Service) ([]*calendar.Event, error) {
-	sinceTime, err := time.ParseInLocation("2006-01-02", listSince, time.Now().Location())
+func fetchRangeEvents(svc *gcal.Service, p *dateexpr.Parser) (*gcal.ListResult, error) {
+	since, err := p.Parse(listSince)
 	if err != nil {
-		return nil, fmt.Errorf("invalid since date format (expected YYYY-MM-DD): %w", err)
+		return nil, fmt.Errorf("invalid since date: %w", err)
 	}
-	tmin := time.Date(sinceTime.Year(), sinceTime.Month(), sinceTime.Day(), 0, 0, 0, 0, sinceTime.Location()).Format(time.RFC3339)
+	tmin := since.Start.Format(time.RFC3339)
 
 	var tmax string
 	if listTo != "" {
-		toTime, err := time.ParseInLocation("2006-01-02", listTo, time.Now().Location())
+		to, err := p.Parse(listTo)
 		if err != nil {
-			return nil, fmt.Errorf("invalid to date format (expected YYYY-MM-DD): %w", err)
+			return nil, fmt.Errorf("invalid to date: %w", err)
 		}
-		tmax = time.Date(toTime.Year(), toTime.Month(), toTime.Day(), 23, 59, 59, 59, toTime.Location()).Format(time.RFC3339)
+		tmax = to.End.Format(time.RFC3339)
 	}
 
-	events := make([]*calendar.Event, 0)
-	for _, cid := range svc.CalendarIDList {
-		call := svc.Calendar.Events.List(cid).ShowDeleted(false).
-			SingleEvents(true).TimeMin(tmin).OrderBy("startTime")
-		if tmax != "" {
-			call = call.TimeMax(tmax)
-		}
-		if listMaxResults > 0 {
-			call = call.MaxResults(listMaxResults)
-		}
-		result, err := call.Do()
-		if err != nil {
-			return nil, err
-		}
-		events = append(events, result.Items...)
+	return fetchEvents(svc, tmin, tmax)
+}
+
+// viewRange returns the days shown by --week, --month or --days, which contain or start at --date
+func viewRange(p *dateexpr.Parser) (dateexpr.Range, error) {
+	base, err := p.Parse(listDate)
+	if err != nil {
+		return dateexpr.Range{}, fmt.Errorf("invalid date: %w", err)
+	}
+	day := dateexpr.Day(base.Start).Start
+
+	var start, end time.Time
+	switch {
+	case listWeek:
+		start = day.AddDate(0, 0, -((int(day.Weekday()) - int(p.WeekStart) + 7) % 7))
+		end = start.AddDate(0, 0, 7)
+	case listMonth:
+		start = time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+		end = start.AddDate(0, 1, 0)
+	default:
+		start = day
+		end = start.AddDate(0, 0, listDays)
+	}
+	return dateexpr.Range{Start: start, End: end}, nil
+}
+
+// fetchEvents fetches events between tmin and tmax (tmax may be empty) from all calendars
+func fetchEvents(svc *gcal.Service, tmin, tmax string) (*gcal.ListResult, error) {
+	result, err := svc.ListEvents(context.Background(), gcal.ListOptions{
+		TimeMin:    tmin,
+		TimeMax:    tmax,
+		MaxResults: listFetchLimit(),
+		Filter:     listFilter(),
+		Partial:    listPartial,
+	})
+	if err != nil {
+		return nil, err
+	}
+	reportCalendarErrors(result.Errors)
+	return result, nil
+}
+
+// listFetchLimit returns the number of events to fetch from each calendar for --max-results.
+// The first events of each calendar by start time are enough when they are sorted by start;
+// sorting by another field needs every event first.
+func listFetchLimit() int64 {
+	if listSort != "start" {
+		return 0
+	}
+	return listMaxResults
+}
+
+// listFilter returns the filter dropping declined events while fetching, unless they are included
+func listFilter() func(*calendar.Event) bool {
+	if listIncludeDeclined {
+		return nil
+	}
+	return func(e *calendar.Event) bool { return !isDeclined(e) }
+}
+
+// reportCalendarErrors prints the calendars that could not be fetched in partial mode to stderr
+func reportCalendarErrors(errs []*gcal.CalendarError) {
+	if len(errs) == 0 {
+		return
+	}
+	fmt.Fprintln(os.Stderr, "Some calendars could not be fetched:")
+	for _, e := range errs {
+		fmt.Fprintf(os.Stderr, "  %s\n", e)
 	}
-	return events, nil
 }
 
 func filterDeclinedEvents(events []*calendar.Event) []*calendar.Event {
@@ -229,12 +391,23 @@ func sortEvents(events []*calendar.Event, sortBy string) {
 	}
 }
 
-func outputEvents(w io.Writer, events []*calendar.Event, format string) error {
+// outputEvents writes events in format
+func outputEvents(w io.Writer, events []*calendar.Event, format string, opts *outputOptions) error {
 	switch format {
 	case "json":
 		return outputJSON(w, events)
 	case "table":
-		return outputTable(w, events)
+		return outputTable(w, events, opts)
+	case "csv":
+		return outputCSV(w, events, opts)
+	case "tsv":
+		return outputTSV(w, events, opts)
+	case "markdown":
+		return outputMarkdown(w, events, opts)
+	case "template":
+		return outputTemplate(w, events, opts)
+	case "ics":
+		return outputICS(w, events)
 	default:
 		return fmt.Errorf("unknown output format: %s", format)
 	}
@@ -249,14 +422,14 @@ func outputJSON(w io.Writer, events []*calendar.Event) error {
 	return nil
 }
 
-func outputTable(w io.Writer, events []*calendar.Event) error {
+func outputTable(w io.Writer, events []*calendar.Event, opts *outputOptions) error {
 	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(tw, "START\tEND\tTITLE")
+	if !opts.noHeader {
+		fmt.Fprintln(tw, tableHeader(opts.columns))
+	}
 
 	for _, e := range events {
-		start := formatEventTime(e.Start)
-		end := formatEventTime(e.End)
-		fmt.Fprintf(tw, "%s\t%s\t%s\n", start, end, e.Summary)
+		fmt.Fprintln(tw, tableRow(opts.columns, columnRow{event: e, calendar: opts.calendars[e]}))
 	}
 
 	return tw.Flush()
@@ -281,11 +454,21 @@ func formatEventTime(t *calendar.EventDateTime) string {
 
 func init() {
 	rootCmd.AddCommand(listCmd)
-	listCmd.Flags().StringVarP(&listDate, "date", "d", time.Now().Format("2006-01-02"), "Date to list events (YYYY-MM-DD)")
-	listCmd.Flags().StringVarP(&listSince, "since", "s", "", "Start date for range query (YYYY-MM-DD)")
-	listCmd.Flags().StringVarP(&listTo, "to", "t", "", "End date for range query (YYYY-MM-DD)")
-	listCmd.Flags().Int64VarP(&listMaxResults, "max-results", "n", 0, "Maximum number of results")
-	listCmd.Flags().StringVarP(&listOutput, "output", "o", "table", "Output format: table, json")
+	listCmd.Flags().StringVarP(&listDate, "date", "d", "today", "Date to list events (YYYY-MM-DD, today, +3d, monday, this week, ...)")
+	listCmd.Flags().StringVarP(&listSince, "since", "s", "", "Start date for range query (YYYY-MM-DD, today, -1w, ...)")
+	listCmd.Flags().StringVarP(&listTo, "to", "t", "", "End date for range query (YYYY-MM-DD, friday, next month, ...)")
+	listCmd.Flags().Int64VarP(&listMaxResults, "max-results", "n", 0, "Maximum number of results across all calendars")
+	listCmd.Flags().StringVarP(&listOutput, "output", "o", "table", "Output format: "+strings.Join(outputFormats, ", "))
 	listCmd.Flags().StringVar(&listSort, "sort", "start", "Sort by: start, updated")
 	listCmd.Flags().BoolVar(&listIncludeDeclined, "include-declined", false, "Include declined events")
+	listCmd.Flags().BoolVar(&listWeek, "week", false, "Show the week containing --date, grouped by day")
+	listCmd.Flags().BoolVar(&listMonth, "month", false, "Show the month containing --date, grouped by day")
+	listCmd.Flags().IntVar(&listDays, "days", 0, "Show N days starting at --date, grouped by day")
+	listCmd.Flags().StringVar(&listWeekStart, "week-start", "", "First day of the week (default from week_start config, monday)")
+	listCmd.Flags().StringSliceVar(&listColumns, "columns", []string{}, "Table columns as name or name:width (default from list.columns config, start,end,title)")
+	listCmd.Flags().BoolVar(&listNoHeader, "no-header", false, "Omit the header row of table, csv and tsv output")
+	listCmd.Flags().StringVar(&listTemplate, "template", "", "Go text/template rendered for each event with --output template")
+	listCmd.Flags().StringVar(&listTemplateFile, "template-file", "", "File containing the template for --output template")
+	listCmd.Flags().BoolVar(&listTemplateAll, "template-all", false, "Render the template once with all events (.Events, .Count)")
+	listCmd.Flags().BoolVar(&listPartial, "partial", false, "Show events from calendars that succeeded when others fail")
 }
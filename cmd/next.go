@@ -0,0 +1,218 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"text/template"
+	"time"
+
+	"github.com/longkey1/gcal/internal/gcal"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/calendar/v3"
+)
+
+// exitNoUpcomingEvent is the exit status of gcal next when there is no event
+const exitNoUpcomingEvent = 2
+
+var (
+	nextWithin  time.Duration
+	nextFormat  string
+	nextAllDay  bool
+	nextPartial bool
+)
+
+var nextCmd = &cobra.Command{
+	Use:   "next",
+	Short: "Show the next upcoming event",
+	Long: `Show the event in progress or the next event starting within --within,
+across all configured calendars, with a countdown. Declined events are skipped,
+and so are all-day events unless --all-day is given.
+When there is no such event nothing is printed and the exit status is 2, which
+makes the command easy to use in status bars and shell prompts.`,
+	Example: `  # Show the next event
+  gcal next
+
+  # Only look at the next two hours
+  gcal next --within 2h
+
+  # Custom output for a status bar
+  gcal next --format '{{.Countdown}}: {{.Summary | truncate 20}}'`,
+	Args:    cobra.NoArgs,
+	PreRunE: validateNextFlags,
+	RunE:    runNext,
+}
+
+// nextEvent is the data the --format template is executed with
+type nextEvent struct {
+	templateEvent
+	// Countdown is e.g. "in 12m" or "now, 25m left"
+	Countdown string
+	// Ongoing reports whether the event has started
+	Ongoing bool
+	// Until is the time until the event starts, or until it ends if it is ongoing
+	Until time.Duration
+}
+
+func validateNextFlags(cmd *cobra.Command, args []string) error {
+	if nextWithin <= 0 {
+		return fmt.Errorf("--within must be positive")
+	}
+	return nil
+}
+
+func runNext(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	var tmpl *template.Template
+	if nextFormat != "" {
+		tmpl, err = parseOutputTemplate(nextFormat, "")
+		if err != nil {
+			return err
+		}
+	}
+
+	ctx := context.Background()
+	svc, err := gcal.NewService(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("unable to create gcal service: %w", err)
+	}
+
+	now := time.Now()
+	result, err := svc.ListEvents(ctx, gcal.ListOptions{
+		TimeMin: now.Format(time.RFC3339),
+		TimeMax: now.Add(nextWithin).Format(time.RFC3339),
+		Partial: nextPartial,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to retrieve events: %w", err)
+	}
+	reportCalendarErrors(result.Errors)
+
+	event, ok := findNextEvent(result.Events, now, nextAllDay)
+	if !ok {
+		cmd.SilenceErrors = true
+		return &exitError{code: exitNoUpcomingEvent}
+	}
+
+	data := newNextEvent(event, result.CalendarIDs[event.event], now)
+	if tmpl != nil {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return err
+		}
+		if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+		_, err = os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	fmt.Println(formatNextEvent(data, now))
+	return nil
+}
+
+// nextCandidate is an event with its resolved start and end
+type nextCandidate struct {
+	event *calendar.Event
+	start time.Time
+	end   time.Time
+}
+
+// findNextEvent returns the ongoing or next event at now, skipping declined and cancelled events.
+// Ongoing events win over upcoming ones, and among them the one that started last.
+func findNextEvent(events []*calendar.Event, now time.Time, allDay bool) (nextCandidate, bool) {
+	var best nextCandidate
+	found := false
+	for _, e := range events {
+		if isDeclined(e) || e.Status == "cancelled" {
+			continue
+		}
+		start, end, isAllDay, err := eventSpan(e)
+		if err != nil || (isAllDay && !allDay) || !end.After(now) {
+			continue
+		}
+		c := nextCandidate{event: e, start: start, end: end}
+		if !found || betterNext(c, best, now) {
+			best, found = c, true
+		}
+	}
+	return best, found
+}
+
+func betterNext(c, best nextCandidate, now time.Time) bool {
+	cOngoing, bestOngoing := !c.start.After(now), !best.start.After(now)
+	if cOngoing != bestOngoing {
+		return cOngoing
+	}
+	if cOngoing {
+		return c.start.After(best.start)
+	}
+	return c.start.Before(best.start)
+}
+
+func newNextEvent(c nextCandidate, calendarID string, now time.Time) nextEvent {
+	data := nextEvent{templateEvent: newTemplateEvent(c.event, calendarID)}
+	if c.start.After(now) {
+		data.Until = c.start.Sub(now)
+		data.Countdown = "in " + formatCountdown(data.Until)
+	} else {
+		data.Ongoing = true
+		data.Until = c.end.Sub(now)
+		data.Countdown = "now, " + formatCountdown(data.Until) + " left"
+	}
+	return data
+}
+
+// formatCountdown formats a duration rounded up to the minute, with days when it is long, e.g. "12m" or "1d2h"
+func formatCountdown(d time.Duration) string {
+	d = (d + time.Minute - 1).Truncate(time.Minute)
+	if d < 24*time.Hour {
+		return formatDuration(d)
+	}
+	days := int(d / (24 * time.Hour))
+	rest := d % (24 * time.Hour)
+	if rest < time.Hour {
+		return fmt.Sprintf("%dd", days)
+	}
+	return fmt.Sprintf("%dd%dh", days, int(rest/time.Hour))
+}
+
+// formatNextEvent formats the default output, e.g. "10:00 Team Meeting (in 12m)"
+func formatNextEvent(e nextEvent, now time.Time) string {
+	if e.AllDay {
+		return fmt.Sprintf("%s (%s)", e.Summary, e.Countdown)
+	}
+	start, today := e.Start.Local(), now.Local()
+	layout := "15:04"
+	if start.YearDay() != today.YearDay() || start.Year() != today.Year() {
+		layout = "Mon 15:04"
+	}
+	return fmt.Sprintf("%s %s (%s)", start.Format(layout), e.Summary, e.Countdown)
+}
+
+func init() {
+	rootCmd.AddCommand(nextCmd)
+	nextCmd.Flags().DurationVar(&nextWithin, "within", 24*time.Hour, "Only consider events starting within this duration")
+	nextCmd.Flags().StringVar(&nextFormat, "format", "", "Go text/template for the output (event fields plus .Countdown, .Ongoing, .Until)")
+	nextCmd.Flags().BoolVar(&nextAllDay, "all-day", false, "Include all-day events")
+	nextCmd.Flags().BoolVar(&nextPartial, "partial", false, "Use the calendars that succeeded when others fail")
+}
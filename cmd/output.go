@@ -0,0 +1,188 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/longkey1/gcal/internal/ical"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/calendar/v3"
+)
+
+// outputOptions holds the settings of the output formats
+type outputOptions struct {
+	// calendars maps events to the calendar they belong to, nil when unknown
+	calendars map[*calendar.Event]string
+	// columns are the columns of the table format
+	columns []tableColumn
+	// noHeader omits the header row of the table, csv and tsv formats
+	noHeader bool
+	// template is the template of the template format
+	template *template.Template
+	// templateAll renders all events with one execution of template instead of one per event
+	templateAll bool
+}
+
+// newOutputOptions returns options with the default table columns
+func newOutputOptions(calendars map[*calendar.Event]string) *outputOptions {
+	columns, _ := parseColumns(defaultColumns)
+	return &outputOptions{calendars: calendars, columns: columns}
+}
+
+// outputFormats are the formats supported by outputEvents, all of which list and search accept
+var outputFormats = []string{"table", "json", "csv", "tsv", "markdown", "template", "ics"}
+
+// resultOutputFormats are the formats of add, edit and invites, which have no --template
+var resultOutputFormats = []string{"table", "json", "csv", "tsv", "markdown", "ics"}
+
+// validateOutputFormat checks that format is one of the formats a command accepts
+func validateOutputFormat(format string, formats []string) error {
+	for _, f := range formats {
+		if f == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid output format: %s (valid: %s)", format, strings.Join(formats, ", "))
+}
+
+// validateTemplateFlags checks that --template or --template-file is given exactly when the output format is template
+func validateTemplateFlags(cmd *cobra.Command, format string) error {
+	inline, file := cmd.Flags().Lookup("template").Changed, cmd.Flags().Lookup("template-file").Changed
+	if format == "template" && !inline && !file {
+		return fmt.Errorf("--output template requires --template or --template-file")
+	}
+	if format != "template" && (inline || file) {
+		return fmt.Errorf("--template and --template-file require --output template")
+	}
+	if inline && file {
+		return fmt.Errorf("cannot use --template and --template-file together")
+	}
+	return nil
+}
+
+// exportHeader is the header row of the csv, tsv and markdown formats
+var exportHeader = []string{"start", "end", "title", "calendar_id", "location", "organizer", "event_id"}
+
+// exportRow returns the fields of an event for the csv, tsv and markdown formats
+func exportRow(e *calendar.Event, opts *outputOptions) []string {
+	organizer := ""
+	if e.Organizer != nil {
+		organizer = e.Organizer.Email
+	}
+	return []string{
+		formatEventISO(e.Start),
+		formatEventISO(e.End),
+		e.Summary,
+		opts.calendars[e],
+		e.Location,
+		organizer,
+		e.Id,
+	}
+}
+
+// formatEventISO formats an event time as an RFC3339 timestamp, or as a date for all-day events
+func formatEventISO(t *calendar.EventDateTime) string {
+	if t == nil {
+		return ""
+	}
+	if t.DateTime != "" {
+		return t.DateTime
+	}
+	return t.Date
+}
+
+func outputCSV(w io.Writer, events []*calendar.Event, opts *outputOptions) error {
+	cw := csv.NewWriter(w)
+	if !opts.noHeader {
+		if err := cw.Write(exportHeader); err != nil {
+			return err
+		}
+	}
+	for _, e := range events {
+		if err := cw.Write(exportRow(e, opts)); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// tsvEscaper escapes the characters that would break a tab-separated row
+var tsvEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+func outputTSV(w io.Writer, events []*calendar.Event, opts *outputOptions) error {
+	rows := make([][]string, 0, len(events)+1)
+	if !opts.noHeader {
+		rows = append(rows, exportHeader)
+	}
+	for _, e := range events {
+		rows = append(rows, exportRow(e, opts))
+	}
+	for _, row := range rows {
+		fields := make([]string, len(row))
+		for i, f := range row {
+			fields[i] = tsvEscaper.Replace(f)
+		}
+		if _, err := fmt.Fprintln(w, strings.Join(fields, "\t")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// markdownEscaper escapes the characters that would break a Markdown table cell
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "|", `\|`, "\r\n", "<br>", "\n", "<br>", "\r", "<br>")
+
+func outputMarkdown(w io.Writer, events []*calendar.Event, opts *outputOptions) error {
+	writeRow := func(row []string) error {
+		cells := make([]string, len(row))
+		for i, c := range row {
+			cells[i] = markdownEscaper.Replace(c)
+		}
+		_, err := fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | "))
+		return err
+	}
+
+	if err := writeRow(exportHeader); err != nil {
+		return err
+	}
+	separator := make([]string, len(exportHeader))
+	for i := range separator {
+		separator[i] = "---"
+	}
+	if err := writeRow(separator); err != nil {
+		return err
+	}
+	for _, e := range events {
+		if err := writeRow(exportRow(e, opts)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// outputICS writes events as an iCalendar (RFC 5545) stream.
+// Events are listed as single events, so each occurrence is written as an event of its own.
+func outputICS(w io.Writer, events []*calendar.Event) error {
+	enc := ical.NewEncoder(w)
+	enc.Expanded = true
+	return enc.Encode(events)
+}
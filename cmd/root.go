@@ -16,10 +16,12 @@ limitations under the License.
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 
+	"github.com/longkey1/gcal/internal/dateexpr"
 	"github.com/longkey1/gcal/internal/gcal"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -28,6 +30,7 @@ import (
 var (
 	cfgFile        string
 	calendarIDList []string
+	concurrency    int
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -40,15 +43,47 @@ directly from the terminal.`,
 	SilenceUsage: true,
 }
 
+// exitError ends the program with a specific exit status.
+// Commands returning it should set SilenceErrors when there is nothing to report.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	if e.err == nil {
+		return fmt.Sprintf("exit status %d", e.code)
+	}
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	cobra.CheckErr(rootCmd.Execute())
+	err := rootCmd.Execute()
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.code)
+	}
+	cobra.CheckErr(err)
 }
 
 func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.config/gcal/config.toml)")
 	rootCmd.PersistentFlags().StringSliceVarP(&calendarIDList, "calendar-id-list", "c", []string{}, "Calendar ID List")
+	rootCmd.PersistentFlags().IntVar(&concurrency, "concurrency", 0, fmt.Sprintf("Maximum number of calendars fetched in parallel (default %d)", gcal.DefaultConcurrency))
+}
+
+// confirm asks a yes/no question on stdout and reports whether the answer was yes
+func confirm(prompt string) bool {
+	fmt.Printf("%s [y/N]: ", prompt)
+	var response string
+	fmt.Scanln(&response)
+	return response == "y" || response == "Y"
 }
 
 // loadConfig reads in config file and returns the configuration.
@@ -83,5 +118,21 @@ func loadConfig() (*gcal.Config, error) {
 		config.CalendarIDList = calendarIDList
 	}
 
+	// Override concurrency from command line flag
+	if concurrency > 0 {
+		config.Concurrency = concurrency
+	}
+
 	return config, nil
 }
+
+// newDateParser returns a date expression parser that honors the configured week start
+func newDateParser(cfg *gcal.Config) (*dateexpr.Parser, error) {
+	weekStart, err := cfg.WeekStartDay()
+	if err != nil {
+		return nil, fmt.Errorf("invalid week_start: %w", err)
+	}
+	p := dateexpr.New()
+	p.WeekStart = weekStart
+	return p, nil
+}
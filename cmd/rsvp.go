@@ -0,0 +1,96 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/longkey1/gcal/internal/gcal"
+	"github.com/spf13/cobra"
+)
+
+var (
+	rsvpComment  string
+	rsvpCalendar string
+	rsvpNotify   bool
+)
+
+// rsvpResponses maps the responses accepted on the command line to attendee response statuses
+var rsvpResponses = map[string]string{
+	"accept":    gcal.ResponseAccepted,
+	"decline":   gcal.ResponseDeclined,
+	"tentative": gcal.ResponseTentative,
+}
+
+var rsvpCmd = &cobra.Command{
+	Use:   "rsvp <event-id> accept|decline|tentative",
+	Short: "Respond to an invitation",
+	Long: `Accept, decline or tentatively accept an event you are invited to.
+The calendar that owns the event is looked up in calendar_id_list unless
+--calendar is given. For recurring events, the ID of an occurrence responds to
+that occurrence only and the ID of the series responds to all occurrences.
+The organizer is only notified with --notify.`,
+	Example: `  # Accept an invitation
+  gcal rsvp abc123 accept
+
+  # Decline with a comment and notify the organizer
+  gcal rsvp abc123 decline --comment "On vacation" --notify`,
+	Args:    cobra.ExactArgs(2),
+	PreRunE: validateRsvpFlags,
+	RunE:    runRsvp,
+}
+
+func validateRsvpFlags(cmd *cobra.Command, args []string) error {
+	if _, ok := rsvpResponses[strings.ToLower(args[1])]; !ok {
+		return fmt.Errorf("invalid response: %s (valid: accept, decline, tentative)", args[1])
+	}
+	return nil
+}
+
+func runRsvp(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	svc, err := gcal.NewWriteService(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("unable to create gcal service: %w", err)
+	}
+
+	cid, event, err := svc.FindEvent(ctx, rsvpCalendar, args[0])
+	if err != nil {
+		return fmt.Errorf("unable to find event: %w", err)
+	}
+
+	status := rsvpResponses[strings.ToLower(args[1])]
+	if _, err := svc.Respond(ctx, cid, event, status, rsvpComment, rsvpNotify); err != nil {
+		return fmt.Errorf("unable to respond to event: %w", wrapWriteError(err))
+	}
+
+	fmt.Printf("Responded %s to %s (%s).\n", status, event.Summary, formatEventDateTime(event.Start))
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(rsvpCmd)
+	rsvpCmd.Flags().StringVar(&rsvpComment, "comment", "", "Comment sent with the response")
+	rsvpCmd.Flags().StringVar(&rsvpCalendar, "calendar", "", "Calendar the event belongs to (default: search calendar_id_list)")
+	rsvpCmd.Flags().BoolVar(&rsvpNotify, "notify", false, "Notify the organizer and other attendees of the response")
+}
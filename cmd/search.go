@@ -0,0 +1,236 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/longkey1/gcal/internal/gcal"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/calendar/v3"
+)
+
+var (
+	searchSince            string
+	searchTo               string
+	searchCalendar         string
+	searchMaxResults       int64
+	searchOutput           string
+	searchColumns          []string
+	searchNoHeader         bool
+	searchTemplate         string
+	searchTemplateFile     string
+	searchMatchTitle       string
+	searchMatchDescription string
+	searchMatchLocation    string
+	searchPartial          bool
+)
+
+// searchDefaultColumns are the table columns of search results
+var searchDefaultColumns = []string{"date", "start", "end", "title", "calendar"}
+
+var searchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Search events by keyword",
+	Long: `Search the events of all configured calendars for a keyword.
+The query is matched by Google Calendar against the title, description, location,
+attendees and other text fields of each event, between --since and --to, which
+default to 90 days before and after today.
+The results can be narrowed further with regular expressions that are matched
+locally against the title, description or location.`,
+	Example: `  # Find events mentioning a project
+  gcal search "project apollo"
+
+  # Search the whole of last year
+  gcal search review --since "last year" --to "last year"
+
+  # Only events whose title starts with "1on1"
+  gcal search 1on1 --match-title '^1on1'
+
+  # Output as CSV
+  gcal search offsite -o csv`,
+	Args:    cobra.MinimumNArgs(1),
+	PreRunE: validateSearchFlags,
+	RunE:    runSearch,
+}
+
+func validateSearchFlags(cmd *cobra.Command, args []string) error {
+	if err := validateOutputFormat(searchOutput, outputFormats); err != nil {
+		return err
+	}
+	if err := validateTemplateFlags(cmd, searchOutput); err != nil {
+		return err
+	}
+	if searchMaxResults < 0 {
+		return fmt.Errorf("--max-results must not be negative")
+	}
+	return nil
+}
+
+func runSearch(cmd *cobra.Command, args []string) error {
+	query := strings.Join(args, " ")
+	if strings.TrimSpace(query) == "" {
+		return fmt.Errorf("empty query")
+	}
+
+	filters, err := newSearchFilters()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	columnSpecs := searchDefaultColumns
+	if cmd.Flags().Lookup("columns").Changed {
+		columnSpecs = searchColumns
+	}
+	columns, err := parseColumns(columnSpecs)
+	if err != nil {
+		return err
+	}
+
+	parser, err := newDateParser(cfg)
+	if err != nil {
+		return err
+	}
+	since, err := parser.Parse(searchSince)
+	if err != nil {
+		return fmt.Errorf("invalid since date: %w", err)
+	}
+	to, err := parser.Parse(searchTo)
+	if err != nil {
+		return fmt.Errorf("invalid to date: %w", err)
+	}
+
+	ctx := context.Background()
+	svc, err := gcal.NewService(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("unable to create gcal service: %w", err)
+	}
+	if searchCalendar != "" {
+		cid, err := svc.ResolveCalendarID(searchCalendar)
+		if err != nil {
+			return err
+		}
+		svc.CalendarIDList = []string{cid}
+	}
+
+	result, err := svc.ListEvents(ctx, gcal.ListOptions{
+		TimeMin: since.Start.Format(time.RFC3339),
+		TimeMax: to.End.Format(time.RFC3339),
+		Query:   query,
+		Partial: searchPartial,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to search events: %w", err)
+	}
+	reportCalendarErrors(result.Errors)
+
+	events := make([]*calendar.Event, 0, len(result.Events))
+	for _, e := range result.Events {
+		if filters.match(e) {
+			events = append(events, e)
+		}
+	}
+	sortEvents(events, "start")
+
+	truncated := false
+	if searchMaxResults > 0 && int64(len(events)) > searchMaxResults {
+		events = events[:searchMaxResults]
+		truncated = true
+	}
+
+	opts := &outputOptions{calendars: result.CalendarIDs, columns: columns, noHeader: searchNoHeader}
+	if searchOutput == "template" {
+		opts.template, err = parseOutputTemplate(searchTemplate, searchTemplateFile)
+		if err != nil {
+			return err
+		}
+	}
+	if searchOutput == "table" && len(events) == 0 {
+		fmt.Println("No events found.")
+		return nil
+	}
+	if err := outputEvents(os.Stdout, events, searchOutput, opts); err != nil {
+		return fmt.Errorf("unable to output events: %w", err)
+	}
+
+	if truncated && searchOutput == "table" {
+		fmt.Fprintf(os.Stderr, "\n(showing the first %d events; more are available, raise --max-results to see them)\n", len(events))
+	}
+	return nil
+}
+
+// searchFilters are the regular expressions events must match locally; nil ones match anything
+type searchFilters struct {
+	title       *regexp.Regexp
+	description *regexp.Regexp
+	location    *regexp.Regexp
+}
+
+func newSearchFilters() (*searchFilters, error) {
+	f := &searchFilters{}
+	for _, m := range []struct {
+		flag string
+		expr string
+		re   **regexp.Regexp
+	}{
+		{"--match-title", searchMatchTitle, &f.title},
+		{"--match-description", searchMatchDescription, &f.description},
+		{"--match-location", searchMatchLocation, &f.location},
+	} {
+		if m.expr == "" {
+			continue
+		}
+		re, err := regexp.Compile(m.expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", m.flag, err)
+		}
+		*m.re = re
+	}
+	return f, nil
+}
+
+func (f *searchFilters) match(e *calendar.Event) bool {
+	return (f.title == nil || f.title.MatchString(e.Summary)) &&
+		(f.description == nil || f.description.MatchString(e.Description)) &&
+		(f.location == nil || f.location.MatchString(e.Location))
+}
+
+func init() {
+	rootCmd.AddCommand(searchCmd)
+	searchCmd.Flags().StringVarP(&searchSince, "since", "s", "-90d", "Search events from this date (YYYY-MM-DD, -1y, last month, ...)")
+	searchCmd.Flags().StringVarP(&searchTo, "to", "t", "+90d", "Search events until this date (YYYY-MM-DD, +1y, next month, ...)")
+	searchCmd.Flags().StringVar(&searchCalendar, "calendar", "", "Search only this calendar from calendar_id_list")
+	searchCmd.Flags().Int64VarP(&searchMaxResults, "max-results", "n", 0, "Maximum number of results across all calendars")
+	searchCmd.Flags().StringVarP(&searchOutput, "output", "o", "table", "Output format: "+strings.Join(outputFormats, ", "))
+	searchCmd.Flags().StringSliceVar(&searchColumns, "columns", []string{}, "Table columns as name or name:width (default: date,start,end,title,calendar)")
+	searchCmd.Flags().BoolVar(&searchNoHeader, "no-header", false, "Omit the header row of table, csv and tsv output")
+	searchCmd.Flags().StringVar(&searchTemplate, "template", "", "Go text/template rendered for each event with --output template")
+	searchCmd.Flags().StringVar(&searchTemplateFile, "template-file", "", "File containing the template for --output template")
+	searchCmd.Flags().StringVar(&searchMatchTitle, "match-title", "", "Only keep events whose title matches this regular expression")
+	searchCmd.Flags().StringVar(&searchMatchDescription, "match-description", "", "Only keep events whose description matches this regular expression")
+	searchCmd.Flags().StringVar(&searchMatchLocation, "match-location", "", "Only keep events whose location matches this regular expression")
+	searchCmd.Flags().BoolVar(&searchPartial, "partial", false, "Show results from calendars that succeeded when others fail")
+}
@@ -0,0 +1,334 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/longkey1/gcal/internal/gcal"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/calendar/v3"
+)
+
+var (
+	showCalendar string
+	showOutput   string
+)
+
+var showCmd = &cobra.Command{
+	Use:   "show <event-id>",
+	Short: "Show the details of an event",
+	Long: `Show all details of a single event: its times with time zone, recurrence,
+location, description, organizer, attendees and their responses, conference,
+attachments, reminders and links.
+The calendar that owns the event is looked up in calendar_id_list unless
+--calendar is given.`,
+	Example: `  # Show an event
+  gcal show abc123
+
+  # Show an event as JSON
+  gcal show abc123 -o json | jq .attendees`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: validateShowFlags,
+	RunE:    runShow,
+}
+
+func validateShowFlags(cmd *cobra.Command, args []string) error {
+	if showOutput != "text" && showOutput != "json" {
+		return fmt.Errorf("invalid output format: %s (valid: text, json)", showOutput)
+	}
+	return nil
+}
+
+func runShow(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	svc, err := gcal.NewService(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("unable to create gcal service: %w", err)
+	}
+
+	cid, event, err := svc.FindEvent(ctx, showCalendar, args[0])
+	if err != nil {
+		return fmt.Errorf("unable to find event: %w", err)
+	}
+
+	if showOutput == "json" {
+		b, err := json.MarshalIndent(event, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s\n", b)
+		return nil
+	}
+
+	// Occurrences do not carry the recurrence rules of their series.
+	// The series may not be readable, e.g. on a shared calendar; the occurrence is then shown without them.
+	recurrence := event.Recurrence
+	if event.RecurringEventId != "" {
+		if master, err := svc.Calendar.Events.Get(cid, event.RecurringEventId).Context(ctx).Do(); err == nil {
+			recurrence = master.Recurrence
+		}
+	}
+	return outputEventDetails(os.Stdout, event, cid, recurrence)
+}
+
+// outputEventDetails writes the human-readable detail page of an event
+func outputEventDetails(w io.Writer, e *calendar.Event, calendarID string, recurrence []string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	field := func(name, value string) {
+		if value == "" {
+			return
+		}
+		lines := strings.Split(value, "\n")
+		fmt.Fprintf(tw, "%s:\t%s\n", name, lines[0])
+		for _, l := range lines[1:] {
+			fmt.Fprintf(tw, "\t%s\n", l)
+		}
+	}
+
+	title := e.Summary
+	if title == "" {
+		title = "(No title)"
+	}
+	field("Title", title)
+	field("When", formatEventSpan(e))
+	if len(recurrence) > 0 {
+		field("Repeats", gcal.DescribeRecurrence(recurrence))
+	}
+	field("Calendar", calendarID)
+	field("Location", e.Location)
+	if e.Status != "" && e.Status != "confirmed" {
+		field("Status", e.Status)
+	}
+	if e.Transparency == "transparent" {
+		field("Show as", "free")
+	}
+	if e.Visibility != "" && e.Visibility != "default" {
+		field("Visibility", e.Visibility)
+	}
+	if e.Organizer != nil {
+		field("Organizer", formatPerson(e.Organizer.DisplayName, e.Organizer.Email))
+	}
+	field("Attendees", formatAttendees(e.Attendees))
+	field("Conference", formatConference(e))
+	field("Attachments", formatAttachments(e.Attachments))
+	field("Reminders", formatReminders(e.Reminders))
+	field("ID", e.Id)
+	field("Link", e.HtmlLink)
+	if e.Source != nil {
+		field("Source", e.Source.Url)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if description := stripHTML(e.Description); description != "" {
+		fmt.Fprintf(w, "\n%s\n", description)
+	}
+	return nil
+}
+
+// formatEventSpan formats the start and end of an event with its time zone,
+// e.g. "2024-01-15 Mon 10:00 - 11:00 (Asia/Tokyo)"
+func formatEventSpan(e *calendar.Event) string {
+	start, end, allDay, err := eventSpan(e)
+	if err != nil {
+		return ""
+	}
+	if allDay {
+		last := end.AddDate(0, 0, -1)
+		if !last.After(start) {
+			return start.Format("2006-01-02 Mon") + " (all-day)"
+		}
+		return start.Format("2006-01-02 Mon") + " - " + last.Format("2006-01-02 Mon") + " (all-day)"
+	}
+
+	loc := time.Local
+	if e.Start.TimeZone != "" {
+		if l, err := time.LoadLocation(e.Start.TimeZone); err == nil {
+			loc = l
+		}
+	}
+	start, end = start.In(loc), end.In(loc)
+	text := start.Format("2006-01-02 Mon 15:04") + " - "
+	if end.Year() == start.Year() && end.YearDay() == start.YearDay() {
+		text += end.Format("15:04")
+	} else {
+		text += end.Format("2006-01-02 Mon 15:04")
+	}
+	zone, _ := start.Zone()
+	if name := loc.String(); name != "Local" && name != zone {
+		zone += ", " + name
+	}
+	return fmt.Sprintf("%s (%s, %s)", text, zone, formatDuration(end.Sub(start)))
+}
+
+func formatPerson(name, email string) string {
+	if name == "" {
+		return email
+	}
+	if email == "" {
+		return name
+	}
+	return fmt.Sprintf("%s <%s>", name, email)
+}
+
+// attendeeResponses are the labels of the response statuses of attendees
+var attendeeResponses = map[string]string{
+	"accepted":    "accepted",
+	"declined":    "declined",
+	"tentative":   "maybe",
+	"needsAction": "awaiting",
+}
+
+func formatAttendees(attendees []*calendar.EventAttendee) string {
+	lines := make([]string, 0, len(attendees))
+	for _, a := range attendees {
+		response := attendeeResponses[a.ResponseStatus]
+		if response == "" {
+			response = a.ResponseStatus
+		}
+		var notes []string
+		if a.Organizer {
+			notes = append(notes, "organizer")
+		}
+		if a.Self {
+			notes = append(notes, "you")
+		}
+		if a.Optional {
+			notes = append(notes, "optional")
+		}
+		if a.Resource {
+			notes = append(notes, "room")
+		}
+		line := fmt.Sprintf("[%s] %s", response, formatPerson(a.DisplayName, a.Email))
+		if len(notes) > 0 {
+			line += " (" + strings.Join(notes, ", ") + ")"
+		}
+		if a.Comment != "" {
+			line += ": " + a.Comment
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func formatConference(e *calendar.Event) string {
+	cd := e.ConferenceData
+	if cd == nil {
+		return e.HangoutLink
+	}
+	var lines []string
+	if cd.ConferenceSolution != nil && cd.ConferenceSolution.Name != "" {
+		lines = append(lines, cd.ConferenceSolution.Name)
+	}
+	for _, ep := range cd.EntryPoints {
+		line := ep.Uri
+		if ep.Label != "" && ep.EntryPointType != "video" {
+			line = ep.Label
+		}
+		if ep.Pin != "" {
+			line += " (PIN: " + ep.Pin + ")"
+		} else if ep.Passcode != "" {
+			line += " (passcode: " + ep.Passcode + ")"
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return e.HangoutLink
+	}
+	return strings.Join(lines, "\n")
+}
+
+func formatAttachments(attachments []*calendar.EventAttachment) string {
+	lines := make([]string, 0, len(attachments))
+	for _, a := range attachments {
+		if a.Title == "" {
+			lines = append(lines, a.FileUrl)
+			continue
+		}
+		lines = append(lines, a.Title+" "+a.FileUrl)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func formatReminders(r *calendar.EventReminders) string {
+	if r == nil {
+		return ""
+	}
+	if r.UseDefault {
+		return "calendar default"
+	}
+	if len(r.Overrides) == 0 {
+		return "none"
+	}
+	lines := make([]string, 0, len(r.Overrides))
+	for _, o := range r.Overrides {
+		lines = append(lines, fmt.Sprintf("%s %s before", o.Method, formatCountdown(time.Duration(o.Minutes)*time.Minute)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+var (
+	htmlLinkPattern  = regexp.MustCompile(`(?is)<a\s[^>]*href\s*=\s*["']([^"']*)["'][^>]*>(.*?)</a>`)
+	htmlBreakPattern = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|li|h[1-6]|tr)>`)
+	htmlItemPattern  = regexp.MustCompile(`(?i)<li[^>]*>`)
+	htmlTagPattern   = regexp.MustCompile(`<[^>]*>`)
+	blankLinePattern = regexp.MustCompile(`\n{3,}`)
+)
+
+// stripHTML converts the HTML of an event description to plain text, keeping line breaks and link targets
+func stripHTML(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = htmlLinkPattern.ReplaceAllStringFunc(s, func(m string) string {
+		parts := htmlLinkPattern.FindStringSubmatch(m)
+		href, text := parts[1], htmlTagPattern.ReplaceAllString(parts[2], "")
+		if text == "" || html.UnescapeString(text) == html.UnescapeString(href) {
+			return href
+		}
+		return text + " (" + href + ")"
+	})
+	s = htmlBreakPattern.ReplaceAllString(s, "\n")
+	s = htmlItemPattern.ReplaceAllString(s, "- ")
+	s = htmlTagPattern.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	s = strings.ReplaceAll(s, "\u00a0", " ")
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(l, " \t")
+	}
+	return strings.TrimSpace(blankLinePattern.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
+
+func init() {
+	rootCmd.AddCommand(showCmd)
+	showCmd.Flags().StringVar(&showCalendar, "calendar", "", "Calendar the event belongs to (default: search calendar_id_list)")
+	showCmd.Flags().StringVarP(&showOutput, "output", "o", "text", "Output format: text, json")
+}
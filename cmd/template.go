@@ -0,0 +1,184 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// templateEvent is the data an event template is executed with
+type templateEvent struct {
+	ID             string
+	Summary        string
+	Description    string
+	Location       string
+	Status         string
+	Link           string
+	Calendar       string
+	Organizer      string
+	Attendees      []string
+	ResponseStatus string
+	ConferenceLink string
+	Start          time.Time
+	End            time.Time
+	Duration       time.Duration
+	AllDay         bool
+	Updated        time.Time
+	// Event is the event as returned by the API
+	Event *calendar.Event
+}
+
+// templateResult is the data a template is executed with when rendering all events at once
+type templateResult struct {
+	Events []templateEvent
+	Count  int
+}
+
+// templateFuncs are the helper functions available in templates
+var templateFuncs = template.FuncMap{
+	// date formats a time with a Go layout, e.g. {{date "Mon 15:04" .Start}}
+	"date": func(layout string, t time.Time) string {
+		return t.Format(layout)
+	},
+	// duration formats a duration compactly, e.g. 1h30m
+	"duration": formatDuration,
+	// truncate shortens a string to n characters, e.g. {{.Summary | truncate 20}}
+	"truncate": func(n int, s string) string {
+		return truncate(s, n)
+	},
+	// pad pads a string with spaces on the right to n characters
+	"pad": func(n int, s string) string {
+		if l := len([]rune(s)); l < n {
+			return s + strings.Repeat(" ", n-l)
+		}
+		return s
+	},
+	// padLeft pads a string with spaces on the left to n characters
+	"padLeft": func(n int, s string) string {
+		if l := len([]rune(s)); l < n {
+			return strings.Repeat(" ", n-l) + s
+		}
+		return s
+	},
+	// tz converts a time to a time zone, e.g. {{tz "Asia/Tokyo" .Start}}
+	"tz": func(name string, t time.Time) (time.Time, error) {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return t.In(loc), nil
+	},
+	// local converts a time to the local time zone
+	"local": func(t time.Time) time.Time {
+		return t.Local()
+	},
+	// join joins strings with a separator
+	"join": func(sep string, s []string) string {
+		return strings.Join(s, sep)
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// parseOutputTemplate parses a template given inline or, if text is empty, read from file
+func parseOutputTemplate(text, file string) (*template.Template, error) {
+	if file != "" {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read template file: %w", err)
+		}
+		text = string(b)
+	}
+	if text == "" {
+		return nil, fmt.Errorf("template output requires --template or --template-file")
+	}
+	tmpl, err := template.New("output").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// newTemplateEvent converts an event to template data
+func newTemplateEvent(e *calendar.Event, calendarID string) templateEvent {
+	te := templateEvent{
+		ID:             e.Id,
+		Summary:        e.Summary,
+		Description:    e.Description,
+		Location:       e.Location,
+		Status:         e.Status,
+		Link:           e.HtmlLink,
+		Calendar:       calendarID,
+		ConferenceLink: conferenceLink(e),
+		Event:          e,
+	}
+	if e.Organizer != nil {
+		te.Organizer = e.Organizer.Email
+	}
+	for _, a := range e.Attendees {
+		te.Attendees = append(te.Attendees, a.Email)
+		if a.Self {
+			te.ResponseStatus = a.ResponseStatus
+		}
+	}
+	if start, end, allDay, err := eventSpan(e); err == nil {
+		te.Start, te.End, te.AllDay = start, end, allDay
+		te.Duration = end.Sub(start)
+	}
+	if updated, err := time.Parse(time.RFC3339, e.Updated); err == nil {
+		te.Updated = updated
+	}
+	return te
+}
+
+// outputTemplate renders each event through the template, or all events at once if opts.templateAll is set.
+// A newline is added after each event unless the template already ends with one.
+func outputTemplate(w io.Writer, events []*calendar.Event, opts *outputOptions) error {
+	if opts.template == nil {
+		return fmt.Errorf("template output requires --template or --template-file")
+	}
+
+	data := make([]templateEvent, 0, len(events))
+	for _, e := range events {
+		data = append(data, newTemplateEvent(e, opts.calendars[e]))
+	}
+
+	if opts.templateAll {
+		return opts.template.Execute(w, templateResult{Events: data, Count: len(data)})
+	}
+
+	for _, te := range data {
+		var buf bytes.Buffer
+		if err := opts.template.Execute(&buf, te); err != nil {
+			return err
+		}
+		if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+// Package dateexpr resolves date expressions given on the command line to time ranges.
+//
+// Supported expressions (case-insensitive):
+//
+//	today, tomorrow, yesterday, now
+//	+3d, -1w, +2m, +1y          days, weeks, months or years from today
+//	monday ... sunday           the next such day, today included
+//	next friday, last friday    the next (or previous) such day, today excluded
+//	this week, next week, last week
+//	this month, next month, last month
+//	this year, next year, last year
+//	2024-01-15                  a date
+//	2024-01                     a month
+//	2024-W03                    an ISO 8601 week
+//	2024-01-15T10:00:00+09:00   an RFC 3339 timestamp
+//	2024-01-15T10:00            a local date and time
+//
+// Expressions are resolved relative to the clock of a Parser, in the clock's time zone.
+package dateexpr
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Range is a half-open time range [Start, End).
+// Expressions for a point in time yield a range with Start equal to End.
+type Range struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Day returns the range of the calendar day containing t
+func Day(t time.Time) Range {
+	start := startOfDay(t)
+	return Range{Start: start, End: start.AddDate(0, 0, 1)}
+}
+
+// IsInstant reports whether the range is a single point in time
+func (r Range) IsInstant() bool {
+	return r.Start.Equal(r.End)
+}
+
+// IsDay reports whether the range is exactly one calendar day
+func (r Range) IsDay() bool {
+	return !r.IsInstant() && startOfDay(r.Start).Equal(r.Start) && r.Start.AddDate(0, 0, 1).Equal(r.End)
+}
+
+// Parser resolves expressions relative to a clock
+type Parser struct {
+	// Now returns the current time; its location is used for all results
+	Now func() time.Time
+	// WeekStart is the first day of the week for "this week" and similar expressions
+	WeekStart time.Weekday
+}
+
+// New returns a Parser using the local clock and weeks starting on Monday
+func New() *Parser {
+	return &Parser{Now: time.Now, WeekStart: time.Monday}
+}
+
+// Parse resolves an expression using a Parser returned by New
+func Parse(s string) (Range, error) {
+	return New().Parse(s)
+}
+
+var (
+	offsetPattern  = regexp.MustCompile(`^([+-])(\d+)([dwmy])$`)
+	isoWeekPattern = regexp.MustCompile(`^(\d{4})-w(\d{2})$`)
+)
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+	"sun":       time.Sunday,
+	"mon":       time.Monday,
+	"tue":       time.Tuesday,
+	"wed":       time.Wednesday,
+	"thu":       time.Thursday,
+	"fri":       time.Friday,
+	"sat":       time.Saturday,
+}
+
+// Parse resolves an expression to a range
+func (p *Parser) Parse(s string) (Range, error) {
+	expr := strings.ToLower(strings.Join(strings.Fields(s), " "))
+	now := p.Now()
+	loc := now.Location()
+	today := startOfDay(now)
+
+	switch expr {
+	case "":
+		return Range{}, fmt.Errorf("empty date expression")
+	case "now":
+		return Range{Start: now, End: now}, nil
+	case "today":
+		return Day(today), nil
+	case "tomorrow":
+		return Day(today.AddDate(0, 0, 1)), nil
+	case "yesterday":
+		return Day(today.AddDate(0, 0, -1)), nil
+	}
+
+	if m := offsetPattern.FindStringSubmatch(expr); m != nil {
+		n, err := strconv.Atoi(m[2])
+		if err != nil {
+			return Range{}, fmt.Errorf("invalid offset %q: %w", s, err)
+		}
+		if m[1] == "-" {
+			n = -n
+		}
+		switch m[3] {
+		case "d":
+			return Day(today.AddDate(0, 0, n)), nil
+		case "w":
+			return Day(today.AddDate(0, 0, 7*n)), nil
+		case "m":
+			return Day(today.AddDate(0, n, 0)), nil
+		case "y":
+			return Day(today.AddDate(n, 0, 0)), nil
+		}
+	}
+
+	if wd, ok := weekdays[expr]; ok {
+		return Day(today.AddDate(0, 0, daysUntil(today.Weekday(), wd))), nil
+	}
+
+	if prefix, rest, ok := strings.Cut(expr, " "); ok {
+		if r, ok := p.parseRelative(prefix, rest, today); ok {
+			return r, nil
+		}
+	}
+
+	if m := isoWeekPattern.FindStringSubmatch(expr); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		week, _ := strconv.Atoi(m[2])
+		start, err := isoWeekStart(year, week, loc)
+		if err != nil {
+			return Range{}, err
+		}
+		return Range{Start: start, End: start.AddDate(0, 0, 7)}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, strings.ToUpper(expr)); err == nil {
+		return Range{Start: t, End: t}, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04"} {
+		if t, err := time.ParseInLocation(layout, strings.ToUpper(expr), loc); err == nil {
+			return Range{Start: t, End: t}, nil
+		}
+	}
+	if t, err := time.ParseInLocation("2006-01-02", expr, loc); err == nil {
+		return Day(t), nil
+	}
+	if t, err := time.ParseInLocation("2006-01", expr, loc); err == nil {
+		return Range{Start: t, End: t.AddDate(0, 1, 0)}, nil
+	}
+
+	return Range{}, fmt.Errorf("unrecognized date expression %q", s)
+}
+
+// parseRelative handles "this/next/last" followed by a weekday or a period
+func (p *Parser) parseRelative(prefix, unit string, today time.Time) (Range, bool) {
+	var n int
+	switch prefix {
+	case "this":
+		n = 0
+	case "next":
+		n = 1
+	case "last":
+		n = -1
+	default:
+		return Range{}, false
+	}
+
+	if wd, ok := weekdays[unit]; ok {
+		switch n {
+		case 1:
+			return Day(today.AddDate(0, 0, daysUntil(today.AddDate(0, 0, 1).Weekday(), wd)+1)), true
+		case -1:
+			return Day(today.AddDate(0, 0, -daysUntil(wd, today.AddDate(0, 0, -1).Weekday())-1)), true
+		default:
+			return Day(today.AddDate(0, 0, daysUntil(today.Weekday(), wd))), true
+		}
+	}
+
+	switch unit {
+	case "week":
+		start := today.AddDate(0, 0, -daysUntil(p.WeekStart, today.Weekday())+7*n)
+		return Range{Start: start, End: start.AddDate(0, 0, 7)}, true
+	case "month":
+		start := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location()).AddDate(0, n, 0)
+		return Range{Start: start, End: start.AddDate(0, 1, 0)}, true
+	case "year":
+		start := time.Date(today.Year()+n, time.January, 1, 0, 0, 0, 0, today.Location())
+		return Range{Start: start, End: start.AddDate(1, 0, 0)}, true
+	}
+	return Range{}, false
+}
+
+// daysUntil returns the number of days from weekday from to the next weekday to, 0 if they are equal
+func daysUntil(from, to time.Weekday) int {
+	return (int(to) - int(from) + 7) % 7
+}
+
+// isoWeekStart returns the Monday starting ISO week week of year
+func isoWeekStart(year, week int, loc *time.Location) (time.Time, error) {
+	// January 4th is always in week 1
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, loc)
+	start := jan4.AddDate(0, 0, -daysUntil(time.Monday, jan4.Weekday())+7*(week-1))
+	if y, w := start.ISOWeek(); week < 1 || y != year || w != week {
+		return time.Time{}, fmt.Errorf("invalid ISO week %d-W%02d", year, week)
+	}
+	return start, nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,219 @@
+package dateexpr
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s not available: %v", name, err)
+	}
+	return loc
+}
+
+// fixedParser returns a Parser whose clock is stopped at now, given in loc as 2006-01-02T15:04
+func fixedParser(t *testing.T, now string, loc *time.Location, weekStart time.Weekday) *Parser {
+	t.Helper()
+	n, err := time.ParseInLocation("2006-01-02T15:04", now, loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Parser{Now: func() time.Time { return n }, WeekStart: weekStart}
+}
+
+func TestParse(t *testing.T) {
+	loc := mustLoadLocation(t, "America/New_York")
+	// Wednesday
+	p := fixedParser(t, "2024-01-17T10:30", loc, time.Monday)
+
+	tests := []struct {
+		expr  string
+		start string
+		end   string
+	}{
+		{"today", "2024-01-17T00:00", "2024-01-18T00:00"},
+		{"Today", "2024-01-17T00:00", "2024-01-18T00:00"},
+		{"tomorrow", "2024-01-18T00:00", "2024-01-19T00:00"},
+		{"yesterday", "2024-01-16T00:00", "2024-01-17T00:00"},
+		{"now", "2024-01-17T10:30", "2024-01-17T10:30"},
+		{"+3d", "2024-01-20T00:00", "2024-01-21T00:00"},
+		{"-1w", "2024-01-10T00:00", "2024-01-11T00:00"},
+		{"+2m", "2024-03-17T00:00", "2024-03-18T00:00"},
+		{"-1y", "2023-01-17T00:00", "2023-01-18T00:00"},
+		{"monday", "2024-01-22T00:00", "2024-01-23T00:00"},
+		{"fri", "2024-01-19T00:00", "2024-01-20T00:00"},
+		{"next friday", "2024-01-19T00:00", "2024-01-20T00:00"},
+		{"last friday", "2024-01-12T00:00", "2024-01-13T00:00"},
+		// A weekday equal to today is today, unless next or last is given
+		{"wednesday", "2024-01-17T00:00", "2024-01-18T00:00"},
+		{"this wednesday", "2024-01-17T00:00", "2024-01-18T00:00"},
+		{"next wednesday", "2024-01-24T00:00", "2024-01-25T00:00"},
+		{"last wednesday", "2024-01-10T00:00", "2024-01-11T00:00"},
+		{"this week", "2024-01-15T00:00", "2024-01-22T00:00"},
+		{"next week", "2024-01-22T00:00", "2024-01-29T00:00"},
+		{"last week", "2024-01-08T00:00", "2024-01-15T00:00"},
+		{"this month", "2024-01-01T00:00", "2024-02-01T00:00"},
+		{"next month", "2024-02-01T00:00", "2024-03-01T00:00"},
+		{"last month", "2023-12-01T00:00", "2024-01-01T00:00"},
+		{"next year", "2025-01-01T00:00", "2026-01-01T00:00"},
+		{"2024-02-29", "2024-02-29T00:00", "2024-03-01T00:00"},
+		{"2024-02", "2024-02-01T00:00", "2024-03-01T00:00"},
+		{"2024-W03", "2024-01-15T00:00", "2024-01-22T00:00"},
+		{"2024-w01", "2024-01-01T00:00", "2024-01-08T00:00"},
+		// ISO week 1 of 2025 starts in 2024, and 2020 has 53 weeks
+		{"2025-W01", "2024-12-30T00:00", "2025-01-06T00:00"},
+		{"2020-W53", "2020-12-28T00:00", "2021-01-04T00:00"},
+		{"2024-01-15T10:00", "2024-01-15T10:00", "2024-01-15T10:00"},
+		{"2024-01-15 10:00", "2024-01-15T10:00", "2024-01-15T10:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := p.Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.expr, err)
+			}
+			start, _ := time.ParseInLocation("2006-01-02T15:04", tt.start, loc)
+			end, _ := time.ParseInLocation("2006-01-02T15:04", tt.end, loc)
+			if !got.Start.Equal(start) || !got.End.Equal(end) {
+				t.Errorf("Parse(%q) = [%s, %s), want [%s, %s)", tt.expr, got.Start, got.End, start, end)
+			}
+			if got.Start.Location() != loc {
+				t.Errorf("Parse(%q) location = %s, want %s", tt.expr, got.Start.Location(), loc)
+			}
+		})
+	}
+}
+
+func TestParseRFC3339(t *testing.T) {
+	p := fixedParser(t, "2024-01-17T10:30", time.UTC, time.Monday)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"2024-01-15T10:00:00+09:00", time.Date(2024, 1, 15, 1, 0, 0, 0, time.UTC)},
+		{"2024-01-15T10:00:00Z", time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)},
+		{"2024-01-15t10:00:00z", time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)},
+		{"2024-01-15T10:00:00.5-05:00", time.Date(2024, 1, 15, 15, 0, 0, 500000000, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := p.Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.expr, err)
+			}
+			if !got.IsInstant() || !got.Start.Equal(tt.want) {
+				t.Errorf("Parse(%q) = [%s, %s), want the instant %s", tt.expr, got.Start, got.End, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseWeekStart(t *testing.T) {
+	loc := mustLoadLocation(t, "America/New_York")
+
+	// "this week" on Wednesday 2024-01-17 starts on the last WeekStart day, today included
+	tests := []struct {
+		weekStart time.Weekday
+		start     string
+	}{
+		{time.Sunday, "2024-01-14T00:00"},
+		{time.Monday, "2024-01-15T00:00"},
+		{time.Tuesday, "2024-01-16T00:00"},
+		{time.Wednesday, "2024-01-17T00:00"},
+		{time.Thursday, "2024-01-11T00:00"},
+		{time.Friday, "2024-01-12T00:00"},
+		{time.Saturday, "2024-01-13T00:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.weekStart.String(), func(t *testing.T) {
+			p := fixedParser(t, "2024-01-17T10:30", loc, tt.weekStart)
+			start, _ := time.ParseInLocation("2006-01-02T15:04", tt.start, loc)
+
+			for expr, offset := range map[string]int{"this week": 0, "next week": 7, "last week": -7} {
+				got, err := p.Parse(expr)
+				if err != nil {
+					t.Fatalf("Parse(%q) error = %v", expr, err)
+				}
+				want := start.AddDate(0, 0, offset)
+				if !got.Start.Equal(want) || !got.End.Equal(want.AddDate(0, 0, 7)) {
+					t.Errorf("Parse(%q) = [%s, %s), want the week from %s", expr, got.Start, got.End, want)
+				}
+				if got.Start.Weekday() != tt.weekStart {
+					t.Errorf("Parse(%q) starts on %s, want %s", expr, got.Start.Weekday(), tt.weekStart)
+				}
+			}
+		})
+	}
+}
+
+func TestParseDST(t *testing.T) {
+	loc := mustLoadLocation(t, "America/New_York")
+
+	tests := []struct {
+		name  string
+		now   string
+		expr  string
+		start time.Time
+		end   time.Time
+		hours float64
+	}{
+		// Clocks go forward on 2024-03-10, which has 23 hours
+		{"spring forward today", "2024-03-10T12:00", "today",
+			time.Date(2024, 3, 10, 5, 0, 0, 0, time.UTC), time.Date(2024, 3, 11, 4, 0, 0, 0, time.UTC), 23},
+		{"spring forward tomorrow", "2024-03-09T23:30", "tomorrow",
+			time.Date(2024, 3, 10, 5, 0, 0, 0, time.UTC), time.Date(2024, 3, 11, 4, 0, 0, 0, time.UTC), 23},
+		{"spring forward offset", "2024-03-09T12:00", "+1d",
+			time.Date(2024, 3, 10, 5, 0, 0, 0, time.UTC), time.Date(2024, 3, 11, 4, 0, 0, 0, time.UTC), 23},
+		// Clocks go back on 2024-11-03, which has 25 hours
+		{"fall back today", "2024-11-03T01:30", "today",
+			time.Date(2024, 11, 3, 4, 0, 0, 0, time.UTC), time.Date(2024, 11, 4, 5, 0, 0, 0, time.UTC), 25},
+		// A week across the change is 7 calendar days, not 168 hours
+		{"spring forward week", "2024-03-08T12:00", "this week",
+			time.Date(2024, 3, 4, 5, 0, 0, 0, time.UTC), time.Date(2024, 3, 11, 4, 0, 0, 0, time.UTC), 167},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := fixedParser(t, tt.now, loc, time.Monday)
+			got, err := p.Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.expr, err)
+			}
+			if !got.Start.Equal(tt.start) || !got.End.Equal(tt.end) {
+				t.Errorf("Parse(%q) = [%s, %s), want [%s, %s)", tt.expr, got.Start, got.End, tt.start, tt.end)
+			}
+			if h := got.End.Sub(got.Start).Hours(); h != tt.hours {
+				t.Errorf("Parse(%q) spans %v hours, want %v", tt.expr, h, tt.hours)
+			}
+			if tt.expr != "this week" && !got.IsDay() {
+				t.Errorf("Parse(%q).IsDay() = false, want true", tt.expr)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	p := fixedParser(t, "2024-01-17T10:30", time.UTC, time.Monday)
+
+	for _, expr := range []string{
+		"",
+		"   ",
+		"someday",
+		"next fortnight",
+		"+3x",
+		"2024-13-01",
+		"2024-W00",
+		// 2021 has only 52 ISO weeks
+		"2021-W53",
+		"2024-W54",
+	} {
+		t.Run(expr, func(t *testing.T) {
+			if got, err := p.Parse(expr); err == nil {
+				t.Errorf("Parse(%q) = [%s, %s), want an error", expr, got.Start, got.End)
+			}
+		})
+	}
+}
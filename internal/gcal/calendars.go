@@ -0,0 +1,22 @@
+package gcal
+
+import (
+	"context"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// ListCalendars returns the calendars in the user's calendar list, following pages until exhaustion.
+// Hidden calendars are included only if showHidden is set.
+func (s *Service) ListCalendars(ctx context.Context, showHidden bool) ([]*calendar.CalendarListEntry, error) {
+	calendars := make([]*calendar.CalendarListEntry, 0)
+	err := s.Calendar.CalendarList.List().ShowHidden(showHidden).Context(ctx).
+		Pages(ctx, func(list *calendar.CalendarList) error {
+			calendars = append(calendars, list.Items...)
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return calendars, nil
+}
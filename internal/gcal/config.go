@@ -2,7 +2,10 @@ package gcal
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/longkey1/gcal/internal/google"
 	"github.com/spf13/viper"
 )
 
@@ -16,10 +19,32 @@ const (
 
 // Config holds the configuration for gcal
 type Config struct {
-	AuthType                     AuthType `mapstructure:"auth_type"`
-	GoogleApplicationCredentials string   `mapstructure:"application_credentials"`
-	GoogleUserCredentials        string   `mapstructure:"user_credentials"`
-	CalendarIDList               []string `mapstructure:"calendar_id_list"`
+	AuthType                     AuthType   `mapstructure:"auth_type"`
+	GoogleApplicationCredentials string     `mapstructure:"application_credentials"`
+	GoogleUserCredentials        string     `mapstructure:"user_credentials"`
+	TokenStore                   string     `mapstructure:"token_store"`
+	CalendarIDList               []string   `mapstructure:"calendar_id_list"`
+	Concurrency                  int        `mapstructure:"concurrency"`
+	WeekStart                    string     `mapstructure:"week_start"`
+	List                         ListConfig `mapstructure:"list"`
+	Free                         FreeConfig `mapstructure:"free"`
+}
+
+// ListConfig holds the defaults of the list command
+type ListConfig struct {
+	Columns []string `mapstructure:"columns"`
+}
+
+// FreeConfig holds the defaults of the free command
+type FreeConfig struct {
+	// WorkHours are the hours slots are searched in, e.g. "09:00-18:00"
+	WorkHours string `mapstructure:"work_hours"`
+	// WorkDays are the days slots are searched on, e.g. ["mon-fri"]
+	WorkDays    []string      `mapstructure:"work_days"`
+	MinDuration time.Duration `mapstructure:"min_duration"`
+	Buffer      time.Duration `mapstructure:"buffer"`
+	// TimeZone is the time zone of the working hours, the local time zone if empty
+	TimeZone string `mapstructure:"time_zone"`
 }
 
 // LoadConfig loads configuration from viper
@@ -34,6 +59,30 @@ func LoadConfig() (*Config, error) {
 		config.AuthType = AuthTypeOAuth
 	}
 
+	// Default to a plain token file at user_credentials
+	if config.TokenStore == "" {
+		config.TokenStore = google.TokenStoreFile
+	}
+
+	// Default to weeks starting on Monday
+	if config.WeekStart == "" {
+		config.WeekStart = "monday"
+	}
+
+	if config.Concurrency <= 0 {
+		config.Concurrency = DefaultConcurrency
+	}
+
+	if config.Free.WorkHours == "" {
+		config.Free.WorkHours = "09:00-18:00"
+	}
+	if len(config.Free.WorkDays) == 0 {
+		config.Free.WorkDays = []string{"mon-fri"}
+	}
+	if config.Free.MinDuration <= 0 {
+		config.Free.MinDuration = 30 * time.Minute
+	}
+
 	return config, nil
 }
 
@@ -53,3 +102,30 @@ func (c *Config) Validate() error {
 
 	return nil
 }
+
+// NewTokenStore returns the configured store of the OAuth token
+func (c *Config) NewTokenStore() (google.TokenStore, error) {
+	return c.NewTokenStoreOf(c.TokenStore)
+}
+
+// NewTokenStoreOf returns a store of the OAuth token of the given kind
+func (c *Config) NewTokenStoreOf(kind string) (google.TokenStore, error) {
+	return google.NewTokenStore(kind, c.GoogleUserCredentials, c.GoogleApplicationCredentials)
+}
+
+// WeekStartDay returns the configured first day of the week
+func (c *Config) WeekStartDay() (time.Weekday, error) {
+	return ParseWeekday(c.WeekStart)
+}
+
+// ParseWeekday parses an English weekday name such as "monday" or "sun"
+func ParseWeekday(s string) (time.Weekday, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		full := strings.ToLower(d.String())
+		if name == full || name == full[:3] {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid weekday: %s", s)
+}
@@ -0,0 +1,189 @@
+package gcal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// maxPageSize is the largest page size accepted by Events.List
+const maxPageSize = 2500
+
+// ListOptions controls which events ListEvents returns
+type ListOptions struct {
+	// TimeMin and TimeMax bound the events in RFC3339; either may be empty
+	TimeMin string
+	TimeMax string
+	// MaxResults caps the number of events fetched from each calendar, 0 for no limit
+	MaxResults int64
+	// Filter, if set, drops the events it returns false for while fetching, so that
+	// MaxResults counts only the events kept
+	Filter func(*calendar.Event) bool
+	// Partial keeps going when a calendar fails and reports it in ListResult.Errors
+	Partial bool
+	// Recurring returns recurring events as their series and exceptions instead of expanding them
+	// into single events. Events are not ordered, and cancelled occurrences are included.
+	Recurring bool
+	// Query restricts the events to those matching the free text search terms
+	Query string
+}
+
+// ListResult holds the events returned by ListEvents
+type ListResult struct {
+	Events []*calendar.Event
+	// Truncated reports whether any calendar had more events than MaxResults
+	Truncated bool
+	// Errors holds the calendars that failed when Partial is set
+	Errors []*CalendarError
+	// CalendarIDs maps each event to the calendar it was fetched from
+	CalendarIDs map[*calendar.Event]string
+}
+
+// ListEvents fetches events from all calendars in CalendarIDList in parallel,
+// following pages until exhaustion or MaxResults.
+// Unless opts.Partial is set, the first failing calendar cancels the others and its error is returned.
+// If every calendar fails, an error is returned even in partial mode.
+func (s *Service) ListEvents(ctx context.Context, opts ListOptions) (*ListResult, error) {
+	results := make([][]*calendar.Event, len(s.CalendarIDList))
+	more := make([]bool, len(s.CalendarIDList))
+
+	errs := s.ForEachCalendar(ctx, !opts.Partial, func(ctx context.Context, i int, cid string) error {
+		var err error
+		results[i], more[i], err = s.listCalendarEvents(ctx, cid, opts)
+		return err
+	})
+	if len(errs) > 0 && (!opts.Partial || len(errs) == len(s.CalendarIDList)) {
+		return nil, errs[0]
+	}
+
+	result := &ListResult{
+		Events:      make([]*calendar.Event, 0),
+		Errors:      errs,
+		CalendarIDs: make(map[*calendar.Event]string),
+	}
+	for i := range results {
+		for _, e := range results[i] {
+			result.CalendarIDs[e] = s.CalendarIDList[i]
+		}
+		result.Events = append(result.Events, results[i]...)
+		result.Truncated = result.Truncated || more[i]
+	}
+	return result, nil
+}
+
+// listCalendarEvents fetches events of a single calendar page by page.
+// The returned bool reports whether fetching stopped at opts.MaxResults with more events available.
+func (s *Service) listCalendarEvents(ctx context.Context, cid string, opts ListOptions) ([]*calendar.Event, bool, error) {
+	limit := opts.MaxResults
+	events := make([]*calendar.Event, 0)
+	pageToken := ""
+	for {
+		call := s.Calendar.Events.List(cid).ShowDeleted(false).Context(ctx)
+		if !opts.Recurring {
+			call = call.SingleEvents(true).OrderBy("startTime")
+		}
+		if opts.TimeMin != "" {
+			call = call.TimeMin(opts.TimeMin)
+		}
+		if opts.TimeMax != "" {
+			call = call.TimeMax(opts.TimeMax)
+		}
+		if opts.Query != "" {
+			call = call.Q(opts.Query)
+		}
+		if limit > 0 {
+			call = call.MaxResults(min(limit-int64(len(events)), maxPageSize))
+		}
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		result, err := call.Do()
+		if err != nil {
+			return nil, false, err
+		}
+		for _, e := range result.Items {
+			if opts.Filter == nil || opts.Filter(e) {
+				events = append(events, e)
+			}
+		}
+
+		if limit > 0 && int64(len(events)) > limit {
+			return events[:limit], true, nil
+		}
+		if result.NextPageToken == "" {
+			return events, false, nil
+		}
+		if limit > 0 && int64(len(events)) == limit {
+			return events, true, nil
+		}
+		pageToken = result.NextPageToken
+	}
+}
+
+// FindEvent looks up an event by ID and returns it together with the ID of the calendar it belongs to.
+// If calendarID is empty, every calendar in CalendarIDList is searched in order.
+func (s *Service) FindEvent(ctx context.Context, calendarID, eventID string) (string, *calendar.Event, error) {
+	candidates := s.CalendarIDList
+	if calendarID != "" {
+		cid, err := s.ResolveCalendarID(calendarID)
+		if err != nil {
+			return "", nil, err
+		}
+		candidates = []string{cid}
+	}
+
+	for _, cid := range candidates {
+		event, err := s.Calendar.Events.Get(cid, eventID).Context(ctx).Do()
+		if err != nil {
+			if isNotFound(err) {
+				continue
+			}
+			return "", nil, err
+		}
+		return cid, event, nil
+	}
+	return "", nil, fmt.Errorf("event %s not found in any configured calendar", eventID)
+}
+
+// FindEventByICalUID returns the event of a calendar with the given iCalendar UID,
+// or nil if there is none. For recurring events the series is returned.
+func (s *Service) FindEventByICalUID(ctx context.Context, calendarID, uid string) (*calendar.Event, error) {
+	events, err := s.Calendar.Events.List(calendarID).ICalUID(uid).ShowDeleted(false).Context(ctx).Do()
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range events.Items {
+		if e.RecurringEventId == "" {
+			return e, nil
+		}
+	}
+	return nil, nil
+}
+
+// CountInstancesBefore returns the number of occurrences of a recurring event starting before t,
+// including cancelled ones.
+func (s *Service) CountInstancesBefore(ctx context.Context, calendarID, recurringEventID, t string) (int64, error) {
+	var count int64
+	err := s.Calendar.Events.Instances(calendarID, recurringEventID).
+		ShowDeleted(true).TimeMax(t).
+		Pages(ctx, func(events *calendar.Events) error {
+			count += int64(len(events.Items))
+			return nil
+		})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func isNotFound(err error) bool {
+	var gerr *googleapi.Error
+	if errors.As(err, &gerr) {
+		return gerr.Code == http.StatusNotFound || gerr.Code == http.StatusGone
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package gcal
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/longkey1/gcal/internal/google"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/option"
+)
+
+// newPagedService returns a Service for one calendar whose events are served as the given pages,
+// ignoring the page size asked for, and a counter of the pages requested
+func newPagedService(t *testing.T, pages [][]*calendar.Event) (*Service, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		page := 0
+		if token := r.URL.Query().Get("pageToken"); token != "" {
+			page, _ = strconv.Atoi(token)
+		}
+		result := &calendar.Events{Items: pages[page]}
+		if page+1 < len(pages) {
+			result.NextPageToken = strconv.Itoa(page + 1)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}))
+	t.Cleanup(srv.Close)
+
+	cal, err := calendar.NewService(context.Background(), option.WithEndpoint(srv.URL), option.WithHTTPClient(srv.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Service{Calendar: &google.CalendarService{Service: cal}, CalendarIDList: []string{"primary"}}, &requests
+}
+
+func TestListEventsFilter(t *testing.T) {
+	declined := []*calendar.EventAttendee{{Self: true, ResponseStatus: "declined"}}
+	pages := [][]*calendar.Event{
+		{{Id: "e1", Attendees: declined}, {Id: "e2"}},
+		{{Id: "e3"}, {Id: "e4", Attendees: declined}},
+		{{Id: "e5"}, {Id: "e6"}},
+	}
+	notDeclined := func(e *calendar.Event) bool { return len(e.Attendees) == 0 }
+
+	tests := []struct {
+		name          string
+		maxResults    int64
+		filter        func(*calendar.Event) bool
+		want          []string
+		wantTruncated bool
+		wantRequests  int32
+	}{
+		{"no limit", 0, nil, []string{"e1", "e2", "e3", "e4", "e5", "e6"}, false, 3},
+		{"limit", 3, nil, []string{"e1", "e2", "e3"}, true, 2},
+		{"filter without limit", 0, notDeclined, []string{"e2", "e3", "e5", "e6"}, false, 3},
+		// Paging stops once enough events are kept, rather than walking every page
+		{"filter with limit", 2, notDeclined, []string{"e2", "e3"}, true, 2},
+		{"filter with limit within a page", 3, notDeclined, []string{"e2", "e3", "e5"}, true, 3},
+		{"filter with limit above the total", 5, notDeclined, []string{"e2", "e3", "e5", "e6"}, false, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, requests := newPagedService(t, pages)
+			result, err := svc.ListEvents(context.Background(), ListOptions{MaxResults: tt.maxResults, Filter: tt.filter})
+			if err != nil {
+				t.Fatalf("ListEvents() error = %v", err)
+			}
+			var got []string
+			for _, e := range result.Events {
+				got = append(got, e.Id)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ListEvents() = %v, want %v", got, tt.want)
+			}
+			if result.Truncated != tt.wantTruncated {
+				t.Errorf("Truncated = %v, want %v", result.Truncated, tt.wantTruncated)
+			}
+			if n := requests.Load(); n != tt.wantRequests {
+				t.Errorf("pages requested = %d, want %d", n, tt.wantRequests)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package gcal
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// maxFreeBusyItems is the largest number of calendars a FreeBusy query accepts
+const maxFreeBusyItems = 50
+
+// Period is a span of time from Start to End
+type Period struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Duration returns the length of the period
+func (p Period) Duration() time.Duration {
+	return p.End.Sub(p.Start)
+}
+
+// FreeBusy returns the busy periods of each calendar between tmin and tmax.
+// calendarIDs may be any calendars or email addresses the user can see free/busy information of.
+func (s *Service) FreeBusy(ctx context.Context, calendarIDs []string, tmin, tmax time.Time) (map[string][]Period, error) {
+	busy := make(map[string][]Period, len(calendarIDs))
+	for i := 0; i < len(calendarIDs); i += maxFreeBusyItems {
+		chunk := calendarIDs[i:min(i+maxFreeBusyItems, len(calendarIDs))]
+		req := &calendar.FreeBusyRequest{
+			TimeMin: tmin.Format(time.RFC3339),
+			TimeMax: tmax.Format(time.RFC3339),
+		}
+		for _, id := range chunk {
+			req.Items = append(req.Items, &calendar.FreeBusyRequestItem{Id: id})
+		}
+
+		resp, err := s.Calendar.Freebusy.Query(req).Context(ctx).Do()
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range chunk {
+			fb, ok := resp.Calendars[id]
+			if !ok {
+				return nil, &CalendarError{CalendarID: id, Err: fmt.Errorf("no free/busy information returned")}
+			}
+			if len(fb.Errors) > 0 {
+				return nil, &CalendarError{CalendarID: id, Err: fmt.Errorf("free/busy information unavailable: %s", fb.Errors[0].Reason)}
+			}
+			for _, b := range fb.Busy {
+				start, err := time.Parse(time.RFC3339, b.Start)
+				if err != nil {
+					return nil, err
+				}
+				end, err := time.Parse(time.RFC3339, b.End)
+				if err != nil {
+					return nil, err
+				}
+				busy[id] = append(busy[id], Period{Start: start, End: end})
+			}
+		}
+	}
+	return busy, nil
+}
+
+// SlotOptions constrains the slots returned by FreeSlots
+type SlotOptions struct {
+	// WorkStart and WorkEnd are the working hours as offsets from midnight
+	WorkStart time.Duration
+	WorkEnd   time.Duration
+	// WorkDays are the days slots may fall on; all days if empty
+	WorkDays []time.Weekday
+	// MinDuration is the shortest slot returned
+	MinDuration time.Duration
+	// Buffer is kept free before and after every busy period
+	Buffer time.Duration
+	// Location is the time zone of the working hours
+	Location *time.Location
+}
+
+// FreeSlots returns the periods between from and to that are within working hours
+// and do not overlap any busy period, including its buffer.
+func FreeSlots(busy []Period, from, to time.Time, opts SlotOptions) []Period {
+	loc := opts.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	blocked := mergePeriods(busy, opts.Buffer)
+
+	var slots []Period
+	first := from.In(loc)
+	for day := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, loc); day.Before(to); day = day.AddDate(0, 0, 1) {
+		if len(opts.WorkDays) > 0 && !containsWeekday(opts.WorkDays, day.Weekday()) {
+			continue
+		}
+		start := clockTime(day, opts.WorkStart)
+		end := clockTime(day, opts.WorkEnd)
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+
+		for _, b := range blocked {
+			if !start.Before(end) {
+				break
+			}
+			if !b.End.After(start) || !b.Start.Before(end) {
+				continue
+			}
+			if b.Start.After(start) {
+				slots = appendSlot(slots, Period{Start: start, End: b.Start}, opts.MinDuration)
+			}
+			start = b.End
+		}
+		if start.Before(end) {
+			slots = appendSlot(slots, Period{Start: start, End: end}, opts.MinDuration)
+		}
+	}
+	return slots
+}
+
+func appendSlot(slots []Period, p Period, minDuration time.Duration) []Period {
+	if p.Duration() < minDuration || p.Duration() <= 0 {
+		return slots
+	}
+	return append(slots, p)
+}
+
+// clockTime returns the wall clock time d after midnight on day
+func clockTime(day time.Time, d time.Duration) time.Time {
+	h, m := int(d/time.Hour), int(d%time.Hour/time.Minute)
+	return time.Date(day.Year(), day.Month(), day.Day(), h, m, 0, 0, day.Location())
+}
+
+// mergePeriods widens the periods by buffer on both sides and merges overlapping ones, sorted by start
+func mergePeriods(periods []Period, buffer time.Duration) []Period {
+	widened := make([]Period, len(periods))
+	for i, p := range periods {
+		widened[i] = Period{Start: p.Start.Add(-buffer), End: p.End.Add(buffer)}
+	}
+	sort.Slice(widened, func(i, j int) bool { return widened[i].Start.Before(widened[j].Start) })
+
+	var merged []Period
+	for _, p := range widened {
+		if n := len(merged); n > 0 && !p.Start.After(merged[n-1].End) {
+			if p.End.After(merged[n-1].End) {
+				merged[n-1].End = p.End
+			}
+			continue
+		}
+		merged = append(merged, p)
+	}
+	return merged
+}
+
+func containsWeekday(days []time.Weekday, d time.Weekday) bool {
+	for _, w := range days {
+		if w == d {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseWorkHours parses working hours such as "09:00-18:00" into offsets from midnight
+func ParseWorkHours(s string) (time.Duration, time.Duration, error) {
+	from, to, ok := strings.Cut(strings.TrimSpace(s), "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid working hours: %s (expected HH:MM-HH:MM)", s)
+	}
+	start, err := parseClock(from)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid working hours: %s (expected HH:MM-HH:MM)", s)
+	}
+	end, err := parseClock(to)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid working hours: %s (expected HH:MM-HH:MM)", s)
+	}
+	if end <= start {
+		return 0, 0, fmt.Errorf("invalid working hours: %s (end must be after start)", s)
+	}
+	return start, end, nil
+}
+
+// parseClock parses a time of day such as "09:30" or "24:00" into an offset from midnight
+func parseClock(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "24:00" {
+		return 24 * time.Hour, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// ParseWorkDays parses weekday names and ranges such as "mon-fri" or "mon,wed,fri"
+func ParseWorkDays(specs []string) ([]time.Weekday, error) {
+	var days []time.Weekday
+	for _, spec := range specs {
+		for _, part := range strings.Split(spec, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			from, to, isRange := strings.Cut(part, "-")
+			start, err := ParseWeekday(from)
+			if err != nil {
+				return nil, err
+			}
+			if !isRange {
+				days = append(days, start)
+				continue
+			}
+			end, err := ParseWeekday(to)
+			if err != nil {
+				return nil, err
+			}
+			for d := start; ; d = (d + 1) % 7 {
+				days = append(days, d)
+				if d == end {
+					break
+				}
+			}
+		}
+	}
+	return days, nil
+}
@@ -0,0 +1,219 @@
+package gcal
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s not available: %v", name, err)
+	}
+	return loc
+}
+
+// mustParse parses a wall clock time given as 2006-01-02T15:04 in loc
+func mustParse(t *testing.T, s string, loc *time.Location) time.Time {
+	t.Helper()
+	v, err := time.ParseInLocation("2006-01-02T15:04", s, loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+// periods parses "start/end" pairs of wall clock times in loc
+func periods(t *testing.T, loc *time.Location, specs ...string) []Period {
+	t.Helper()
+	var ps []Period
+	for _, spec := range specs {
+		start, end, _ := strings.Cut(spec, "/")
+		ps = append(ps, Period{Start: mustParse(t, start, loc), End: mustParse(t, end, loc)})
+	}
+	return ps
+}
+
+func formatPeriods(ps []Period, loc *time.Location) string {
+	out := make([]string, len(ps))
+	for i, p := range ps {
+		out[i] = p.Start.In(loc).Format("2006-01-02T15:04") + "/" + p.End.In(loc).Format("2006-01-02T15:04")
+	}
+	return fmt.Sprintf("%v", out)
+}
+
+func TestFreeSlots(t *testing.T) {
+	loc := mustLoadLocation(t, "America/New_York")
+	weekdays := []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+
+	tests := []struct {
+		name string
+		busy []string
+		from string
+		to   string
+		opts SlotOptions
+		want []string
+	}{
+		{"no busy periods", nil, "2024-01-15T00:00", "2024-01-16T00:00",
+			SlotOptions{WorkStart: 9 * time.Hour, WorkEnd: 18 * time.Hour},
+			[]string{"2024-01-15T09:00/2024-01-15T18:00"}},
+		{"busy in the middle", []string{"2024-01-15T10:00/2024-01-15T11:00"}, "2024-01-15T00:00", "2024-01-16T00:00",
+			SlotOptions{WorkStart: 9 * time.Hour, WorkEnd: 18 * time.Hour},
+			[]string{"2024-01-15T09:00/2024-01-15T10:00", "2024-01-15T11:00/2024-01-15T18:00"}},
+		{"busy across the working hours", []string{"2024-01-15T08:00/2024-01-15T09:30", "2024-01-15T17:00/2024-01-15T19:00"},
+			"2024-01-15T00:00", "2024-01-16T00:00",
+			SlotOptions{WorkStart: 9 * time.Hour, WorkEnd: 18 * time.Hour},
+			[]string{"2024-01-15T09:30/2024-01-15T17:00"}},
+		{"overlapping and unsorted busy periods", []string{"2024-01-15T13:00/2024-01-15T14:00", "2024-01-15T10:00/2024-01-15T12:00", "2024-01-15T11:00/2024-01-15T13:00"},
+			"2024-01-15T00:00", "2024-01-16T00:00",
+			SlotOptions{WorkStart: 9 * time.Hour, WorkEnd: 18 * time.Hour},
+			[]string{"2024-01-15T09:00/2024-01-15T10:00", "2024-01-15T14:00/2024-01-15T18:00"}},
+		// The buffer applies on both sides, and buffers touching each other close the gap between them
+		{"buffer", []string{"2024-01-15T10:00/2024-01-15T11:00", "2024-01-15T11:30/2024-01-15T12:00"},
+			"2024-01-15T00:00", "2024-01-16T00:00",
+			SlotOptions{WorkStart: 9 * time.Hour, WorkEnd: 18 * time.Hour, Buffer: 15 * time.Minute},
+			[]string{"2024-01-15T09:00/2024-01-15T09:45", "2024-01-15T12:15/2024-01-15T18:00"}},
+		{"minimum duration", []string{"2024-01-15T09:30/2024-01-15T17:00"}, "2024-01-15T00:00", "2024-01-16T00:00",
+			SlotOptions{WorkStart: 9 * time.Hour, WorkEnd: 18 * time.Hour, MinDuration: time.Hour},
+			[]string{"2024-01-15T17:00/2024-01-15T18:00"}},
+		// 2024-01-13 is a Saturday
+		{"work days", nil, "2024-01-12T00:00", "2024-01-16T00:00",
+			SlotOptions{WorkStart: 9 * time.Hour, WorkEnd: 17 * time.Hour, WorkDays: weekdays},
+			[]string{"2024-01-12T09:00/2024-01-12T17:00", "2024-01-15T09:00/2024-01-15T17:00"}},
+		{"all days without work days", nil, "2024-01-13T00:00", "2024-01-15T00:00",
+			SlotOptions{WorkStart: 9 * time.Hour, WorkEnd: 17 * time.Hour},
+			[]string{"2024-01-13T09:00/2024-01-13T17:00", "2024-01-14T09:00/2024-01-14T17:00"}},
+		// Slots end at 24:00, so that each day has its own slots
+		{"24:00", []string{"2024-01-15T12:00/2024-01-15T13:00"}, "2024-01-15T00:00", "2024-01-17T00:00",
+			SlotOptions{WorkStart: 0, WorkEnd: 24 * time.Hour},
+			[]string{"2024-01-15T00:00/2024-01-15T12:00", "2024-01-15T13:00/2024-01-16T00:00", "2024-01-16T00:00/2024-01-17T00:00"}},
+		{"busy across midnight", []string{"2024-01-15T22:00/2024-01-16T02:00"}, "2024-01-15T00:00", "2024-01-17T00:00",
+			SlotOptions{WorkStart: 0, WorkEnd: 24 * time.Hour},
+			[]string{"2024-01-15T00:00/2024-01-15T22:00", "2024-01-16T02:00/2024-01-17T00:00"}},
+		// Slots are clipped to the range, which need not start or end at midnight
+		{"clipped at from and to", nil, "2024-01-15T10:30", "2024-01-16T12:00",
+			SlotOptions{WorkStart: 9 * time.Hour, WorkEnd: 18 * time.Hour},
+			[]string{"2024-01-15T10:30/2024-01-15T18:00", "2024-01-16T09:00/2024-01-16T12:00"}},
+		{"clipped below the minimum duration", nil, "2024-01-15T17:30", "2024-01-16T00:00",
+			SlotOptions{WorkStart: 9 * time.Hour, WorkEnd: 18 * time.Hour, MinDuration: time.Hour},
+			nil},
+		{"after the working hours", nil, "2024-01-15T19:00", "2024-01-16T00:00",
+			SlotOptions{WorkStart: 9 * time.Hour, WorkEnd: 18 * time.Hour},
+			nil},
+		{"fully busy", []string{"2024-01-15T08:00/2024-01-15T19:00"}, "2024-01-15T00:00", "2024-01-16T00:00",
+			SlotOptions{WorkStart: 9 * time.Hour, WorkEnd: 18 * time.Hour},
+			nil},
+		// Working hours are wall clock times on days where clocks change
+		{"spring forward", nil, "2024-03-09T00:00", "2024-03-11T00:00",
+			SlotOptions{WorkStart: 9 * time.Hour, WorkEnd: 17 * time.Hour},
+			[]string{"2024-03-09T09:00/2024-03-09T17:00", "2024-03-10T09:00/2024-03-10T17:00"}},
+		{"fall back", nil, "2024-11-03T00:00", "2024-11-04T00:00",
+			SlotOptions{WorkStart: 9 * time.Hour, WorkEnd: 17 * time.Hour},
+			[]string{"2024-11-03T09:00/2024-11-03T17:00"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Location = loc
+			got := FreeSlots(periods(t, loc, tt.busy...), mustParse(t, tt.from, loc), mustParse(t, tt.to, loc), tt.opts)
+			want := periods(t, loc, tt.want...)
+			if formatPeriods(got, loc) != formatPeriods(want, loc) {
+				t.Errorf("FreeSlots() = %s, want %s", formatPeriods(got, loc), formatPeriods(want, loc))
+			}
+		})
+	}
+}
+
+func TestFreeSlotsDSTDuration(t *testing.T) {
+	loc := mustLoadLocation(t, "America/New_York")
+
+	// A whole day is 23 hours when clocks go forward and 25 hours when they go back
+	tests := []struct {
+		day   string
+		hours float64
+	}{
+		{"2024-03-10", 23},
+		{"2024-11-03", 25},
+		{"2024-01-15", 24},
+	}
+	for _, tt := range tests {
+		t.Run(tt.day, func(t *testing.T) {
+			from := mustParse(t, tt.day+"T00:00", loc)
+			got := FreeSlots(nil, from, from.AddDate(0, 0, 1), SlotOptions{WorkEnd: 24 * time.Hour, Location: loc})
+			if len(got) != 1 || got[0].Duration().Hours() != tt.hours {
+				t.Fatalf("FreeSlots() = %s, want one slot of %v hours", formatPeriods(got, loc), tt.hours)
+			}
+		})
+	}
+}
+
+func TestMergePeriods(t *testing.T) {
+	loc := time.UTC
+	tests := []struct {
+		name   string
+		in     []string
+		buffer time.Duration
+		want   []string
+	}{
+		{"empty", nil, 0, nil},
+		{"disjoint", []string{"2024-01-15T12:00/2024-01-15T13:00", "2024-01-15T09:00/2024-01-15T10:00"}, 0,
+			[]string{"2024-01-15T09:00/2024-01-15T10:00", "2024-01-15T12:00/2024-01-15T13:00"}},
+		{"adjacent", []string{"2024-01-15T09:00/2024-01-15T10:00", "2024-01-15T10:00/2024-01-15T11:00"}, 0,
+			[]string{"2024-01-15T09:00/2024-01-15T11:00"}},
+		{"contained", []string{"2024-01-15T09:00/2024-01-15T12:00", "2024-01-15T10:00/2024-01-15T11:00"}, 0,
+			[]string{"2024-01-15T09:00/2024-01-15T12:00"}},
+		{"joined by the buffer", []string{"2024-01-15T09:00/2024-01-15T10:00", "2024-01-15T10:30/2024-01-15T11:00"}, 15 * time.Minute,
+			[]string{"2024-01-15T08:45/2024-01-15T11:15"}},
+		{"not joined by the buffer", []string{"2024-01-15T09:00/2024-01-15T10:00", "2024-01-15T10:31/2024-01-15T11:00"}, 15 * time.Minute,
+			[]string{"2024-01-15T08:45/2024-01-15T10:15", "2024-01-15T10:16/2024-01-15T11:15"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mergePeriods(periods(t, loc, tt.in...), tt.buffer)
+			if want := periods(t, loc, tt.want...); formatPeriods(got, loc) != formatPeriods(want, loc) {
+				t.Errorf("mergePeriods() = %s, want %s", formatPeriods(got, loc), formatPeriods(want, loc))
+			}
+		})
+	}
+}
+
+func TestParseWorkHours(t *testing.T) {
+	tests := []struct {
+		in        string
+		wantStart time.Duration
+		wantEnd   time.Duration
+		wantErr   bool
+	}{
+		{"09:00-18:00", 9 * time.Hour, 18 * time.Hour, false},
+		{" 9:30 - 17:45 ", 9*time.Hour + 30*time.Minute, 17*time.Hour + 45*time.Minute, false},
+		{"00:00-24:00", 0, 24 * time.Hour, false},
+		{"22:00-24:00", 22 * time.Hour, 24 * time.Hour, false},
+		{"18:00-09:00", 0, 0, true},
+		{"09:00-09:00", 0, 0, true},
+		{"24:00-24:00", 0, 0, true},
+		{"09:00", 0, 0, true},
+		{"09:00-25:00", 0, 0, true},
+		{"09:00-24:30", 0, 0, true},
+		{"0900-1800", 0, 0, true},
+		{"", 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			start, end, err := ParseWorkHours(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseWorkHours(%q) = %s, %s, want an error", tt.in, start, end)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseWorkHours(%q) error = %v", tt.in, err)
+			}
+			if start != tt.wantStart || end != tt.wantEnd {
+				t.Errorf("ParseWorkHours(%q) = %s, %s, want %s, %s", tt.in, start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
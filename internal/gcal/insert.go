@@ -0,0 +1,52 @@
+package gcal
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// InsertRequest is an event to insert into a calendar
+type InsertRequest struct {
+	CalendarID string
+	Event      *calendar.Event
+}
+
+// InsertResult is the outcome of an InsertRequest
+type InsertResult struct {
+	Event *calendar.Event
+	Err   error
+}
+
+// InsertEvents inserts events with at most Concurrency requests running at once.
+// The Go client has no support for batch requests, so every event is sent as its own request.
+// A failing insert does not stop the others; the results are in the order of reqs.
+func (s *Service) InsertEvents(ctx context.Context, reqs []InsertRequest) []InsertResult {
+	limit := s.Concurrency
+	if limit <= 0 {
+		limit = DefaultConcurrency
+	}
+	sem := make(chan struct{}, limit)
+
+	results := make([]InsertResult, len(reqs))
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				results[i].Err = ctx.Err()
+				return
+			}
+
+			results[i].Event, results[i].Err = s.Calendar.Events.Insert(req.CalendarID, req.Event).Context(ctx).Do()
+		}()
+	}
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,81 @@
+package gcal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultConcurrency is the number of calendars accessed in parallel when not configured
+const DefaultConcurrency = 4
+
+// CalendarError is an error that occurred while accessing a single calendar
+type CalendarError struct {
+	CalendarID string
+	Err        error
+}
+
+func (e *CalendarError) Error() string {
+	return fmt.Sprintf("%s: %v", e.CalendarID, e.Err)
+}
+
+func (e *CalendarError) Unwrap() error {
+	return e.Err
+}
+
+// ForEachCalendar calls fn for every calendar in CalendarIDList, with at most Concurrency calls running at once.
+// fn receives the index of the calendar in CalendarIDList so that results can be stored in order.
+// If failFast is true, the first error cancels the context passed to the remaining calls.
+// The returned errors are in CalendarIDList order and exclude calls cancelled because of an earlier failure.
+func (s *Service) ForEachCalendar(ctx context.Context, failFast bool, fn func(ctx context.Context, i int, calendarID string) error) []*CalendarError {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	limit := s.Concurrency
+	if limit <= 0 {
+		limit = DefaultConcurrency
+	}
+	sem := make(chan struct{}, limit)
+
+	errs := make([]*CalendarError, len(s.CalendarIDList))
+	var failed atomic.Bool
+	var wg sync.WaitGroup
+	for i, cid := range s.CalendarIDList {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				errs[i] = &CalendarError{CalendarID: cid, Err: ctx.Err()}
+				return
+			}
+
+			if err := fn(ctx, i, cid); err != nil {
+				errs[i] = &CalendarError{CalendarID: cid, Err: err}
+				if failFast {
+					failed.Store(true)
+					cancel()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	result := make([]*CalendarError, 0)
+	for _, e := range errs {
+		if e == nil {
+			continue
+		}
+		// Calls cancelled because of an earlier failure are not errors of their own
+		if failed.Load() && errors.Is(e.Err, context.Canceled) {
+			continue
+		}
+		result = append(result, e)
+	}
+	return result
+}
@@ -0,0 +1,274 @@
+package gcal
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SplitRecurrence splits the recurrence rules of a series at the occurrence starting at split.
+// head ends the original series just before split, tail continues it from split onwards.
+// before is the number of occurrences preceding split and is used to carry a COUNT limit
+// over to the tail. allDay selects a DATE value for UNTIL instead of a UTC date-time.
+func SplitRecurrence(rules []string, split time.Time, allDay bool, before int64) (head, tail []string) {
+	var until string
+	if allDay {
+		until = split.AddDate(0, 0, -1).Format("20060102")
+	} else {
+		until = split.Add(-time.Second).UTC().Format("20060102T150405Z")
+	}
+
+	for _, rule := range rules {
+		name, value, ok := strings.Cut(rule, ":")
+		if !ok || !strings.EqualFold(name, "RRULE") {
+			head = append(head, rule)
+			tail = append(tail, rule)
+			continue
+		}
+
+		parts := strings.Split(value, ";")
+		headParts := make([]string, 0, len(parts)+1)
+		tailParts := make([]string, 0, len(parts))
+		for _, part := range parts {
+			key, val, _ := strings.Cut(part, "=")
+			switch strings.ToUpper(key) {
+			case "UNTIL":
+				tailParts = append(tailParts, part)
+			case "COUNT":
+				n, err := strconv.ParseInt(val, 10, 64)
+				remaining := n - before
+				if err != nil || remaining < 1 {
+					remaining = 1
+				}
+				tailParts = append(tailParts, "COUNT="+strconv.FormatInt(remaining, 10))
+			default:
+				headParts = append(headParts, part)
+				tailParts = append(tailParts, part)
+			}
+		}
+		headParts = append(headParts, "UNTIL="+until)
+
+		head = append(head, name+":"+strings.Join(headParts, ";"))
+		tail = append(tail, name+":"+strings.Join(tailParts, ";"))
+	}
+	return head, tail
+}
+
+// recurrenceUnits are the singular and plural units of each FREQ value
+var recurrenceUnits = map[string][2]string{
+	"SECONDLY": {"second", "seconds"},
+	"MINUTELY": {"minute", "minutes"},
+	"HOURLY":   {"hour", "hours"},
+	"DAILY":    {"day", "days"},
+	"WEEKLY":   {"week", "weeks"},
+	"MONTHLY":  {"month", "months"},
+	"YEARLY":   {"year", "years"},
+}
+
+// recurrenceFrequencies describe an interval of one
+var recurrenceFrequencies = map[string]string{
+	"SECONDLY": "Every second",
+	"MINUTELY": "Every minute",
+	"HOURLY":   "Hourly",
+	"DAILY":    "Daily",
+	"WEEKLY":   "Weekly",
+	"MONTHLY":  "Monthly",
+	"YEARLY":   "Yearly",
+}
+
+var recurrenceWeekdays = map[string]string{
+	"MO": "Mon", "TU": "Tue", "WE": "Wed", "TH": "Thu", "FR": "Fri", "SA": "Sat", "SU": "Sun",
+}
+
+// DescribeRecurrence describes the recurrence rules of an event in English,
+// e.g. "Every 2 weeks on Mon, Wed until 2024-03-31".
+// Rules that cannot be described are returned as they are.
+func DescribeRecurrence(rules []string) string {
+	var descriptions []string
+	exdates := 0
+	for _, rule := range rules {
+		name, value, _ := strings.Cut(rule, ":")
+		switch {
+		case strings.EqualFold(name, "RRULE"):
+			if d, ok := describeRule(value); ok {
+				descriptions = append(descriptions, d)
+			} else {
+				descriptions = append(descriptions, rule)
+			}
+		case strings.HasPrefix(strings.ToUpper(name), "EXDATE"):
+			exdates += len(strings.Split(value, ","))
+		default:
+			descriptions = append(descriptions, rule)
+		}
+	}
+	text := strings.Join(descriptions, "; ")
+	switch {
+	case exdates == 1:
+		text += ", except 1 date"
+	case exdates > 1:
+		text += ", except " + strconv.Itoa(exdates) + " dates"
+	}
+	return text
+}
+
+func describeRule(rule string) (string, bool) {
+	params := make(map[string]string)
+	for _, part := range strings.Split(rule, ";") {
+		key, val, _ := strings.Cut(part, "=")
+		params[strings.ToUpper(key)] = val
+	}
+
+	freq := strings.ToUpper(params["FREQ"])
+	units, ok := recurrenceUnits[freq]
+	if !ok {
+		return "", false
+	}
+	interval := 1
+	if v, ok := params["INTERVAL"]; ok {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return "", false
+		}
+		interval = n
+	}
+
+	var b strings.Builder
+	if interval == 1 {
+		b.WriteString(recurrenceFrequencies[freq])
+	} else {
+		b.WriteString("Every " + strconv.Itoa(interval) + " " + units[1])
+	}
+
+	if v, ok := params["BYMONTH"]; ok {
+		months, ok := describeList(v, func(s string) (string, bool) {
+			m, err := strconv.Atoi(s)
+			if err != nil || m < 1 || m > 12 {
+				return "", false
+			}
+			return time.Month(m).String()[:3], true
+		})
+		if !ok {
+			return "", false
+		}
+		b.WriteString(" in " + months)
+	}
+	if v, ok := params["BYMONTHDAY"]; ok {
+		days, ok := describeList(v, func(s string) (string, bool) {
+			n, err := strconv.Atoi(s)
+			if err != nil || n == 0 {
+				return "", false
+			}
+			return ordinal(n), true
+		})
+		if !ok {
+			return "", false
+		}
+		b.WriteString(" on the " + days)
+	}
+	if v, ok := params["BYDAY"]; ok {
+		days, ok := describeList(v, describeWeekday)
+		if !ok {
+			return "", false
+		}
+		b.WriteString(" on " + days)
+	}
+
+	for key := range params {
+		switch key {
+		case "FREQ", "INTERVAL", "BYMONTH", "BYMONTHDAY", "BYDAY", "COUNT", "UNTIL", "WKST":
+		default:
+			return "", false
+		}
+	}
+
+	if v, ok := params["COUNT"]; ok {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return "", false
+		}
+		if n == 1 {
+			b.WriteString(", once")
+		} else {
+			b.WriteString(", " + strconv.Itoa(n) + " times")
+		}
+	}
+	if v, ok := params["UNTIL"]; ok {
+		until, err := describeUntil(v)
+		if err != nil {
+			return "", false
+		}
+		b.WriteString(" until " + until)
+	}
+	return b.String(), true
+}
+
+// describeWeekday describes a BYDAY value such as "TU", "2TU" or "-1FR"
+func describeWeekday(s string) (string, bool) {
+	s = strings.ToUpper(s)
+	if len(s) < 2 {
+		return "", false
+	}
+	day, ok := recurrenceWeekdays[s[len(s)-2:]]
+	if !ok {
+		return "", false
+	}
+	if len(s) == 2 {
+		return day, true
+	}
+	n, err := strconv.Atoi(s[:len(s)-2])
+	if err != nil || n == 0 {
+		return "", false
+	}
+	return "the " + ordinal(n) + " " + day, true
+}
+
+func describeList(value string, describe func(string) (string, bool)) (string, bool) {
+	items := strings.Split(value, ",")
+	out := make([]string, len(items))
+	for i, item := range items {
+		d, ok := describe(item)
+		if !ok {
+			return "", false
+		}
+		out[i] = d
+	}
+	return strings.Join(out, ", "), true
+}
+
+// describeUntil formats an UNTIL value as a date, with the local time for date-times
+func describeUntil(v string) (string, error) {
+	if t, err := time.Parse("20060102T150405Z", v); err == nil {
+		return t.Local().Format("2006-01-02 15:04"), nil
+	}
+	if t, err := time.Parse("20060102T150405", v); err == nil {
+		return t.Format("2006-01-02 15:04"), nil
+	}
+	t, err := time.Parse("20060102", v)
+	if err != nil {
+		return "", err
+	}
+	return t.Format("2006-01-02"), nil
+}
+
+// ordinal formats n as an English ordinal, e.g. "2nd", with "last" and "2nd last" for negative values
+func ordinal(n int) string {
+	if n == -1 {
+		return "last"
+	}
+	if n < 0 {
+		return ordinal(-n) + " last"
+	}
+	suffix := "th"
+	switch n % 10 {
+	case 1:
+		suffix = "st"
+	case 2:
+		suffix = "nd"
+	case 3:
+		suffix = "rd"
+	}
+	if n%100 >= 11 && n%100 <= 13 {
+		suffix = "th"
+	}
+	return strconv.Itoa(n) + suffix
+}
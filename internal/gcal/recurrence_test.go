@@ -0,0 +1,70 @@
+package gcal
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSplitRecurrence(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	// 2024-01-22 10:00 in Tokyo is 01:00 UTC
+	timed := time.Date(2024, 1, 22, 10, 0, 0, 0, jst)
+	allDay := time.Date(2024, 1, 22, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		rules    []string
+		split    time.Time
+		allDay   bool
+		before   int64
+		wantHead []string
+		wantTail []string
+	}{
+		{"timed UNTIL in UTC", []string{"RRULE:FREQ=WEEKLY;BYDAY=MO"}, timed, false, 1,
+			[]string{"RRULE:FREQ=WEEKLY;BYDAY=MO;UNTIL=20240122T005959Z"},
+			[]string{"RRULE:FREQ=WEEKLY;BYDAY=MO"}},
+		{"all-day UNTIL as a date", []string{"RRULE:FREQ=DAILY"}, allDay, true, 7,
+			[]string{"RRULE:FREQ=DAILY;UNTIL=20240121"},
+			[]string{"RRULE:FREQ=DAILY"}},
+		// The head ends at the split; the tail keeps the original end
+		{"existing UNTIL", []string{"RRULE:FREQ=WEEKLY;UNTIL=20240401T000000Z;BYDAY=MO"}, timed, false, 1,
+			[]string{"RRULE:FREQ=WEEKLY;BYDAY=MO;UNTIL=20240122T005959Z"},
+			[]string{"RRULE:FREQ=WEEKLY;UNTIL=20240401T000000Z;BYDAY=MO"}},
+		{"existing all-day UNTIL", []string{"RRULE:FREQ=DAILY;UNTIL=20240131"}, allDay, true, 7,
+			[]string{"RRULE:FREQ=DAILY;UNTIL=20240121"},
+			[]string{"RRULE:FREQ=DAILY;UNTIL=20240131"}},
+		// The occurrences before the split are taken from COUNT for the tail
+		{"COUNT carried over", []string{"RRULE:FREQ=WEEKLY;COUNT=10;BYDAY=MO"}, timed, false, 3,
+			[]string{"RRULE:FREQ=WEEKLY;BYDAY=MO;UNTIL=20240122T005959Z"},
+			[]string{"RRULE:FREQ=WEEKLY;COUNT=7;BYDAY=MO"}},
+		{"COUNT with the last occurrence", []string{"RRULE:FREQ=DAILY;COUNT=8"}, allDay, true, 7,
+			[]string{"RRULE:FREQ=DAILY;UNTIL=20240121"},
+			[]string{"RRULE:FREQ=DAILY;COUNT=1"}},
+		// The split occurrence is always kept, even if COUNT is out of step with the instances
+		{"COUNT already reached", []string{"RRULE:FREQ=DAILY;COUNT=5"}, allDay, true, 9,
+			[]string{"RRULE:FREQ=DAILY;UNTIL=20240121"},
+			[]string{"RRULE:FREQ=DAILY;COUNT=1"}},
+		{"lower case", []string{"rrule:freq=weekly;count=4"}, timed, false, 1,
+			[]string{"rrule:freq=weekly;UNTIL=20240122T005959Z"},
+			[]string{"rrule:freq=weekly;COUNT=3"}},
+		// Other lines are kept on both sides as they are
+		{"non-RRULE lines", []string{"EXDATE;TZID=Asia/Tokyo:20240129T100000", "RRULE:FREQ=WEEKLY", "RDATE;VALUE=DATE:20240203"}, timed, false, 1,
+			[]string{"EXDATE;TZID=Asia/Tokyo:20240129T100000", "RRULE:FREQ=WEEKLY;UNTIL=20240122T005959Z", "RDATE;VALUE=DATE:20240203"},
+			[]string{"EXDATE;TZID=Asia/Tokyo:20240129T100000", "RRULE:FREQ=WEEKLY", "RDATE;VALUE=DATE:20240203"}},
+		{"several RRULEs", []string{"RRULE:FREQ=WEEKLY;BYDAY=MO", "RRULE:FREQ=MONTHLY;COUNT=6"}, timed, false, 2,
+			[]string{"RRULE:FREQ=WEEKLY;BYDAY=MO;UNTIL=20240122T005959Z", "RRULE:FREQ=MONTHLY;UNTIL=20240122T005959Z"},
+			[]string{"RRULE:FREQ=WEEKLY;BYDAY=MO", "RRULE:FREQ=MONTHLY;COUNT=4"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			head, tail := SplitRecurrence(tt.rules, tt.split, tt.allDay, tt.before)
+			if !slices.Equal(head, tt.wantHead) {
+				t.Errorf("head = %q, want %q", head, tt.wantHead)
+			}
+			if !slices.Equal(tail, tt.wantTail) {
+				t.Errorf("tail = %q, want %q", tail, tt.wantTail)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package gcal
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// Response statuses of an attendee
+const (
+	ResponseAccepted    = "accepted"
+	ResponseDeclined    = "declined"
+	ResponseTentative   = "tentative"
+	ResponseNeedsAction = "needsAction"
+)
+
+// SelfAttendee returns the attendee entry of the calendar the event was fetched from, or nil if there is none
+func SelfAttendee(e *calendar.Event) *calendar.EventAttendee {
+	for _, a := range e.Attendees {
+		if a.Self {
+			return a
+		}
+	}
+	return nil
+}
+
+// Respond sets the response status of the user's own attendee entry of an event,
+// and its comment unless comment is empty.
+// The organizer and the other attendees are notified only if notify is set.
+func (s *Service) Respond(ctx context.Context, calendarID string, event *calendar.Event, status, comment string, notify bool) (*calendar.Event, error) {
+	if SelfAttendee(event) == nil {
+		return nil, fmt.Errorf("you are not an attendee of %q", event.Summary)
+	}
+
+	// Attendees are replaced as a whole, so the others are sent back unchanged
+	attendees := make([]*calendar.EventAttendee, len(event.Attendees))
+	for i, a := range event.Attendees {
+		attendee := *a
+		if attendee.Self {
+			attendee.ResponseStatus = status
+			if comment != "" {
+				attendee.Comment = comment
+			}
+		}
+		attendees[i] = &attendee
+	}
+
+	sendUpdates := "none"
+	if notify {
+		sendUpdates = "all"
+	}
+	return s.Calendar.Events.Patch(calendarID, event.Id, &calendar.Event{Attendees: attendees}).
+		SendUpdates(sendUpdates).Context(ctx).Do()
+}
@@ -2,6 +2,7 @@ package gcal
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/longkey1/gcal/internal/google"
 )
@@ -10,11 +11,29 @@ import (
 type Service struct {
 	Calendar       *google.CalendarService
 	CalendarIDList []string
+	Concurrency    int
 }
 
-// NewService creates a new gcal service based on the configuration
+// NewService creates a new read-only gcal service based on the configuration
 func NewService(ctx context.Context, config *Config) (*Service, error) {
-	auth := newAuthenticator(config)
+	return newService(ctx, config, google.ReadOnlyScopes)
+}
+
+// NewWriteService creates a new gcal service that can create and modify events
+func NewWriteService(ctx context.Context, config *Config) (*Service, error) {
+	return newService(ctx, config, google.ReadWriteScopes)
+}
+
+// NewManageService creates a new gcal service that can also create, delete and subscribe to calendars
+func NewManageService(ctx context.Context, config *Config) (*Service, error) {
+	return newService(ctx, config, google.ManageScopes)
+}
+
+func newService(ctx context.Context, config *Config, scopes []string) (*Service, error) {
+	auth, err := newAuthenticator(config, scopes)
+	if err != nil {
+		return nil, err
+	}
 
 	calSvc, err := google.NewCalendarService(ctx, auth)
 	if err != nil {
@@ -24,19 +43,42 @@ func NewService(ctx context.Context, config *Config) (*Service, error) {
 	return &Service{
 		Calendar:       calSvc,
 		CalendarIDList: config.CalendarIDList,
+		Concurrency:    config.Concurrency,
 	}, nil
 }
 
-func newAuthenticator(config *Config) google.Authenticator {
+func newAuthenticator(config *Config, scopes []string) (google.Authenticator, error) {
 	switch config.AuthType {
 	case AuthTypeServiceAccount:
-		return google.NewServiceAccountAuthenticator(config.GoogleApplicationCredentials)
+		return google.NewServiceAccountAuthenticator(config.GoogleApplicationCredentials), nil
 	case AuthTypeOAuth:
 		fallthrough
 	default:
+		store, err := config.NewTokenStore()
+		if err != nil {
+			return nil, err
+		}
 		return google.NewOAuthAuthenticator(
 			config.GoogleApplicationCredentials,
-			config.GoogleUserCredentials,
-		)
+			store,
+			scopes...,
+		), nil
+	}
+}
+
+// ResolveCalendarID returns the calendar ID to write to.
+// An empty id selects the first calendar in CalendarIDList; otherwise id must be one of CalendarIDList.
+func (s *Service) ResolveCalendarID(id string) (string, error) {
+	if len(s.CalendarIDList) == 0 {
+		return "", fmt.Errorf("no calendar configured")
+	}
+	if id == "" {
+		return s.CalendarIDList[0], nil
+	}
+	for _, cid := range s.CalendarIDList {
+		if cid == id {
+			return cid, nil
+		}
 	}
+	return "", fmt.Errorf("calendar %s is not in calendar_id_list", id)
 }
@@ -2,13 +2,16 @@ package google
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
-	"os/exec"
-	"runtime"
+	"time"
 
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
@@ -20,72 +23,100 @@ type Authenticator interface {
 	GetClient(ctx context.Context) (*http.Client, error)
 }
 
+// ReadOnlyScopes are the OAuth scopes needed to read calendar events
+var ReadOnlyScopes = []string{calendar.CalendarReadonlyScope}
+
+// ReadWriteScopes are the OAuth scopes needed to create and modify calendar events.
+// The events scope alone does not cover free/busy queries or the calendar list, so the
+// read-only scope is kept for the commands that need them.
+var ReadWriteScopes = []string{calendar.CalendarReadonlyScope, calendar.CalendarEventsScope}
+
+// ManageScopes are the OAuth scopes needed to create, delete and subscribe to calendars
+var ManageScopes = []string{calendar.CalendarScope}
+
+// openBrowser opens the authorization URL of the loopback flow
+var openBrowser = OpenBrowser
+
 // OAuthAuthenticator implements Authenticator using OAuth2
 type OAuthAuthenticator struct {
 	credentialsFile string
-	tokenFile       string
+	store           TokenStore
+	scopes          []string
 }
 
-// NewOAuthAuthenticator creates a new OAuthAuthenticator
-func NewOAuthAuthenticator(credentialsFile, tokenFile string) *OAuthAuthenticator {
+// NewOAuthAuthenticator creates a new OAuthAuthenticator keeping its token in store.
+// If no scopes are given, ReadOnlyScopes is used.
+func NewOAuthAuthenticator(credentialsFile string, store TokenStore, scopes ...string) *OAuthAuthenticator {
+	if len(scopes) == 0 {
+		scopes = ReadOnlyScopes
+	}
 	return &OAuthAuthenticator{
 		credentialsFile: credentialsFile,
-		tokenFile:       tokenFile,
+		store:           store,
+		scopes:          scopes,
 	}
 }
 
 // GetClient returns an authenticated HTTP client using OAuth2
 func (a *OAuthAuthenticator) GetClient(ctx context.Context) (*http.Client, error) {
-	b, err := os.ReadFile(a.credentialsFile)
+	config, err := a.oauthConfig()
 	if err != nil {
-		return nil, fmt.Errorf("unable to read client secret file: %v", err)
+		return nil, err
 	}
 
-	config, err := google.ConfigFromJSON(b, calendar.CalendarReadonlyScope)
+	token, err := a.store.Load()
 	if err != nil {
-		return nil, fmt.Errorf("unable to parse client secret file to config: %v", err)
+		if errors.Is(err, ErrTokenNotFound) {
+			return nil, fmt.Errorf("token not found, please run 'gcal auth' first: %v", err)
+		}
+		return nil, err
 	}
 
-	token, err := a.tokenFromFile()
+	src := newPersistingTokenSource(config.TokenSource(ctx, token), token, a.store.Save)
+	return oauth2.NewClient(ctx, src), nil
+}
+
+// oauthConfig reads the OAuth client configuration from the client secret file
+func (a *OAuthAuthenticator) oauthConfig() (*oauth2.Config, error) {
+	b, err := os.ReadFile(a.credentialsFile)
 	if err != nil {
-		return nil, fmt.Errorf("token not found, please run 'gcal auth' first: %v", err)
+		return nil, fmt.Errorf("unable to read client secret file: %v", err)
 	}
 
-	return config.Client(ctx, token), nil
+	config, err := google.ConfigFromJSON(b, a.scopes...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse client secret file to config: %v", err)
+	}
+	return config, nil
 }
 
-func (a *OAuthAuthenticator) tokenFromFile() (*oauth2.Token, error) {
-	f, err := os.Open(a.tokenFile)
+// clientID returns the OAuth client ID of a client secret file
+func clientID(credentialsFile string) (string, error) {
+	b, err := os.ReadFile(credentialsFile)
 	if err != nil {
-		return nil, err
+		return "", fmt.Errorf("unable to read client secret file: %v", err)
 	}
-	defer f.Close()
-
-	token := &oauth2.Token{}
-	err = json.NewDecoder(f).Decode(token)
-	return token, err
+	config, err := google.ConfigFromJSON(b)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse client secret file to config: %v", err)
+	}
+	return config.ClientID, nil
 }
 
 func (a *OAuthAuthenticator) saveToken(token *oauth2.Token) error {
-	fmt.Printf("Saving credential file to: %s\n", a.tokenFile)
-	f, err := os.Create(a.tokenFile)
-	if err != nil {
+	fmt.Printf("Saving token to %s\n", a.store)
+	if err := a.store.Save(token); err != nil {
 		return fmt.Errorf("unable to cache oauth token: %v", err)
 	}
-	defer f.Close()
-	return json.NewEncoder(f).Encode(token)
+	return nil
 }
 
-// Authenticate runs the OAuth flow with local server callback and saves the token
-func (a *OAuthAuthenticator) Authenticate() error {
-	b, err := os.ReadFile(a.credentialsFile)
-	if err != nil {
-		return fmt.Errorf("unable to read client secret file: %v", err)
-	}
-
-	config, err := google.ConfigFromJSON(b, calendar.CalendarReadonlyScope)
+// Authenticate runs the OAuth flow with local server callback and saves the token.
+// The flow is protected by a random state and PKCE, and gives up when ctx is done.
+func (a *OAuthAuthenticator) Authenticate(ctx context.Context) error {
+	config, err := a.oauthConfig()
 	if err != nil {
-		return fmt.Errorf("unable to parse client secret file to config: %v", err)
+		return err
 	}
 
 	// Find available port
@@ -99,54 +130,65 @@ func (a *OAuthAuthenticator) Authenticate() error {
 	// Override redirect URL
 	config.RedirectURL = redirectURL
 
-	// Channel to receive the authorization code
-	codeChan := make(chan string)
-	errChan := make(chan error)
-
-	// Start local server
-	server := &http.Server{}
-	http.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
-		code := r.URL.Query().Get("code")
-		if code == "" {
-			errChan <- fmt.Errorf("no code in callback")
-			http.Error(w, "No code received", http.StatusBadRequest)
+	state, err := randomState()
+	if err != nil {
+		return err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	// Buffered so that the handler and server never block once the flow has ended
+	codeChan := make(chan string, 1)
+	errChan := make(chan error, 2)
+
+	// Start local server on its own mux, so the flow can run more than once in a process
+	mux := http.NewServeMux()
+	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
+		code, err := callbackCode(r.URL.Query(), state)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			sendOnce(errChan, err)
 			return
 		}
 
 		w.Header().Set("Content-Type", "text/html")
 		fmt.Fprintf(w, `<html><body><h1>Authentication successful!</h1><p>You can close this window.</p></body></html>`)
-		codeChan <- code
+		sendOnce(codeChan, code)
 	})
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	defer server.Close()
 
 	go func() {
 		if err := server.Serve(listener); err != http.ErrServerClosed {
-			errChan <- err
+			sendOnce(errChan, err)
 		}
 	}()
 
 	// Generate auth URL
-	authURL := config.AuthCodeURL("state-token", oauth2.AccessTypeOffline)
+	authURL := config.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.S256ChallengeOption(verifier))
 
 	fmt.Printf("Opening browser for authentication...\n")
 	fmt.Printf("If browser doesn't open, visit this URL:\n%s\n", authURL)
 
 	// Open browser
-	openBrowser(authURL)
+	if err := openBrowser(authURL); err != nil {
+		fmt.Printf("Failed to open browser: %v\n", err)
+	}
 
 	// Wait for callback
 	var code string
 	select {
 	case code = <-codeChan:
 	case err := <-errChan:
-		server.Close()
 		return fmt.Errorf("authentication failed: %v", err)
+	case <-ctx.Done():
+		return fmt.Errorf("timed out waiting for authorization: %v", ctx.Err())
 	}
 
 	// Shutdown server
 	server.Close()
 
 	// Exchange code for token
-	token, err := config.Exchange(context.Background(), code)
+	token, err := config.Exchange(ctx, code, oauth2.VerifierOption(verifier))
 	if err != nil {
 		return fmt.Errorf("unable to retrieve token: %v", err)
 	}
@@ -154,18 +196,39 @@ func (a *OAuthAuthenticator) Authenticate() error {
 	return a.saveToken(token)
 }
 
-func openBrowser(url string) {
-	var err error
-	switch runtime.GOOS {
-	case "darwin":
-		err = exec.Command("open", url).Start()
-	case "linux":
-		err = exec.Command("xdg-open", url).Start()
-	case "windows":
-		err = exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
+// callbackCode returns the authorization code of a redirect to the callback,
+// checking the state and reporting errors returned by the provider
+func callbackCode(query url.Values, state string) (string, error) {
+	if e := query.Get("error"); e != "" {
+		if desc := query.Get("error_description"); desc != "" {
+			return "", fmt.Errorf("authorization denied: %s (%s)", e, desc)
+		}
+		return "", fmt.Errorf("authorization denied: %s", e)
 	}
-	if err != nil {
-		fmt.Printf("Failed to open browser: %v\n", err)
+	if subtle.ConstantTimeCompare([]byte(query.Get("state")), []byte(state)) != 1 {
+		return "", fmt.Errorf("invalid state in callback")
+	}
+	code := query.Get("code")
+	if code == "" {
+		return "", fmt.Errorf("no code in callback")
+	}
+	return code, nil
+}
+
+// randomState returns an unguessable state parameter for an authorization request
+func randomState() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("unable to generate state: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// sendOnce sends v on ch unless ch is full
+func sendOnce[T any](ch chan T, v T) {
+	select {
+	case ch <- v:
+	default:
 	}
 }
 
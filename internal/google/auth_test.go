@@ -0,0 +1,301 @@
+package google
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeAuthServer is a local authorization server: the fake browser registers the PKCE challenge
+// of each code it hands out, and the token endpoint only exchanges a code for its verifier
+type fakeAuthServer struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	challenges map[string]string
+	exchanged  []string
+}
+
+func newFakeAuthServer(t *testing.T) *fakeAuthServer {
+	t.Helper()
+	s := &fakeAuthServer{challenges: map[string]string{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.token))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *fakeAuthServer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.Form.Get("grant_type") != "authorization_code" {
+		http.Error(w, `{"error":"invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+	code := r.Form.Get("code")
+	sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+
+	s.mu.Lock()
+	challenge, ok := s.challenges[code]
+	delete(s.challenges, code)
+	if ok {
+		s.exchanged = append(s.exchanged, code)
+	}
+	s.mu.Unlock()
+
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":"invalid_grant"}`)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"access_token":"access-%s","refresh_token":"refresh-%s","token_type":"Bearer","expires_in":3600}`, code, code)
+}
+
+// newTestAuthenticator returns an authenticator using the fake server, with its token in a temporary file
+func newTestAuthenticator(t *testing.T, srv *fakeAuthServer) (*OAuthAuthenticator, *FileTokenStore) {
+	t.Helper()
+	dir := t.TempDir()
+	secret := fmt.Sprintf(`{"installed":{"client_id":"client-id","client_secret":"client-secret","auth_uri":%q,"token_uri":%q,"redirect_uris":["http://localhost"]}}`,
+		srv.URL+"/auth", srv.URL+"/token")
+	credentials := filepath.Join(dir, "client_secret.json")
+	if err := os.WriteFile(credentials, []byte(secret), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	store := &FileTokenStore{path: filepath.Join(dir, "token.json")}
+	return NewOAuthAuthenticator(credentials, store), store
+}
+
+// fakeBrowser replaces openBrowser for the test. respond is called with the query of the
+// authorization URL and returns the query to redirect to the callback with, or nil to never
+// come back; the status of the callback response is sent on the returned channel.
+func fakeBrowser(t *testing.T, respond func(auth url.Values) url.Values) <-chan int {
+	t.Helper()
+	statuses := make(chan int, 4)
+	orig := openBrowser
+	t.Cleanup(func() { openBrowser = orig })
+	openBrowser = func(authURL string) error {
+		u, err := url.Parse(authURL)
+		if err != nil {
+			return err
+		}
+		query := respond(u.Query())
+		if query == nil {
+			return nil
+		}
+		go func() {
+			resp, err := http.Get(u.Query().Get("redirect_uri") + "?" + query.Encode())
+			if err != nil {
+				statuses <- 0
+				return
+			}
+			resp.Body.Close()
+			statuses <- resp.StatusCode
+		}()
+		return nil
+	}
+	return statuses
+}
+
+// grant returns a fake browser response granting access with the given code
+func (s *fakeAuthServer) grant(code string) func(url.Values) url.Values {
+	return func(auth url.Values) url.Values {
+		if auth.Get("code_challenge_method") == "S256" {
+			s.mu.Lock()
+			s.challenges[code] = auth.Get("code_challenge")
+			s.mu.Unlock()
+		}
+		return url.Values{"code": {code}, "state": {auth.Get("state")}}
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	srv := newFakeAuthServer(t)
+	auth, store := newTestAuthenticator(t, srv)
+	statuses := fakeBrowser(t, srv.grant("code1"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := auth.Authenticate(ctx); err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if status := <-statuses; status != http.StatusOK {
+		t.Errorf("callback status = %d, want %d", status, http.StatusOK)
+	}
+
+	// The token endpoint only accepts the code with the verifier matching its challenge
+	token, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "access-code1" || token.RefreshToken != "refresh-code1" {
+		t.Errorf("saved token = %q/%q, want access-code1/refresh-code1", token.AccessToken, token.RefreshToken)
+	}
+}
+
+func TestAuthenticateStateMismatch(t *testing.T) {
+	srv := newFakeAuthServer(t)
+	auth, store := newTestAuthenticator(t, srv)
+	statuses := fakeBrowser(t, func(auth url.Values) url.Values {
+		return url.Values{"code": {"code1"}, "state": {auth.Get("state") + "x"}}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := auth.Authenticate(ctx)
+	if err == nil || !strings.Contains(err.Error(), "invalid state") {
+		t.Fatalf("Authenticate() error = %v, want invalid state", err)
+	}
+	if status := <-statuses; status != http.StatusBadRequest {
+		t.Errorf("callback status = %d, want %d", status, http.StatusBadRequest)
+	}
+	if len(srv.exchanged) != 0 {
+		t.Errorf("codes exchanged = %v, want none", srv.exchanged)
+	}
+	if _, err := os.Stat(store.path); !os.IsNotExist(err) {
+		t.Errorf("token file exists after a failed flow")
+	}
+}
+
+func TestAuthenticateProviderError(t *testing.T) {
+	srv := newFakeAuthServer(t)
+	auth, _ := newTestAuthenticator(t, srv)
+	statuses := fakeBrowser(t, func(auth url.Values) url.Values {
+		return url.Values{"error": {"access_denied"}, "error_description": {"The user denied access"}, "state": {auth.Get("state")}}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := auth.Authenticate(ctx)
+	if err == nil || !strings.Contains(err.Error(), "access_denied") || !strings.Contains(err.Error(), "The user denied access") {
+		t.Fatalf("Authenticate() error = %v, want access_denied with its description", err)
+	}
+	if status := <-statuses; status != http.StatusBadRequest {
+		t.Errorf("callback status = %d, want %d", status, http.StatusBadRequest)
+	}
+}
+
+func TestAuthenticateTimeout(t *testing.T) {
+	srv := newFakeAuthServer(t)
+	auth, _ := newTestAuthenticator(t, srv)
+	// The user never completes the flow
+	fakeBrowser(t, func(url.Values) url.Values { return nil })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := auth.Authenticate(ctx)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("Authenticate() error = %v, want timed out", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Authenticate() returned after %s, want soon after the timeout", elapsed)
+	}
+}
+
+func TestAuthenticateTwice(t *testing.T) {
+	srv := newFakeAuthServer(t)
+	var mu sync.Mutex
+	n := 0
+	fakeBrowser(t, func(auth url.Values) url.Values {
+		mu.Lock()
+		n++
+		code := fmt.Sprintf("code%d", n)
+		mu.Unlock()
+		return srv.grant(code)(auth)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// One after the other, then at the same time: each flow has its own server and mux
+	for i := 0; i < 2; i++ {
+		auth, _ := newTestAuthenticator(t, srv)
+		if err := auth.Authenticate(ctx); err != nil {
+			t.Fatalf("Authenticate() #%d error = %v", i+1, err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		auth, _ := newTestAuthenticator(t, srv)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- auth.Authenticate(ctx)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("concurrent Authenticate() error = %v", err)
+		}
+	}
+	if len(srv.exchanged) != 4 {
+		t.Errorf("codes exchanged = %v, want 4", srv.exchanged)
+	}
+}
+
+func TestCallbackCode(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    string
+		wantErr string
+	}{
+		{"code", "code=abc&state=s1", "abc", ""},
+		{"state mismatch", "code=abc&state=s2", "", "invalid state"},
+		{"missing state", "code=abc", "", "invalid state"},
+		{"missing code", "state=s1", "", "no code"},
+		{"provider error", "error=access_denied&state=s1", "", "authorization denied: access_denied"},
+		{"provider error with description", "error=access_denied&error_description=denied+by+user", "", "denied by user"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := callbackCode(query, "s1")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("callbackCode() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("callbackCode() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("callbackCode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRandomState(t *testing.T) {
+	a, err := randomState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := randomState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == b {
+		t.Error("randomState() returned the same state twice")
+	}
+	if len(a) < 43 {
+		t.Errorf("randomState() = %q, want at least 256 bits", a)
+	}
+}
@@ -0,0 +1,130 @@
+package google
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// AuthenticateManual runs the OAuth flow without a local server, for machines without a browser.
+// The authorization URL is printed to be opened on any machine, and the URL the browser is
+// redirected to afterwards, or just the code in it, is read from in.
+func (a *OAuthAuthenticator) AuthenticateManual(in io.Reader) error {
+	config, err := a.oauthConfig()
+	if err != nil {
+		return err
+	}
+
+	state, err := randomState()
+	if err != nil {
+		return err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	authURL := config.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.S256ChallengeOption(verifier))
+	fmt.Printf("Visit this URL in a browser on any machine and grant access:\n%s\n\n", authURL)
+	fmt.Printf("The browser is then redirected to %s, which may fail to load.\n", config.RedirectURL)
+	fmt.Print("Paste the URL from the address bar, or the code in it: ")
+
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return fmt.Errorf("unable to read authorization code: %v", err)
+	}
+	code, err := authorizationCode(line, state)
+	if err != nil {
+		return err
+	}
+
+	token, err := config.Exchange(context.Background(), code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return fmt.Errorf("unable to retrieve token: %v", err)
+	}
+
+	return a.saveToken(token)
+}
+
+// authorizationCode extracts the authorization code from a pasted redirect URL or query string,
+// verifying its state, or returns the input as it is if it is the code itself
+func authorizationCode(input, state string) (string, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return "", fmt.Errorf("no authorization code given")
+	}
+	if !strings.Contains(input, "code=") && !strings.Contains(input, "error=") {
+		return input, nil
+	}
+
+	raw := input
+	if u, err := url.Parse(input); err == nil && u.RawQuery != "" {
+		raw = u.RawQuery
+	}
+	query, err := url.ParseQuery(strings.TrimPrefix(raw, "?"))
+	if err != nil {
+		return "", fmt.Errorf("invalid redirect URL: %v", err)
+	}
+	return callbackCode(query, state)
+}
+
+// deviceAuthURL is the device authorization endpoint of the device flow
+var deviceAuthURL = google.Endpoint.DeviceAuthURL
+
+// googleDeviceScopes are the only scopes Google grants with the device authorization grant.
+// Calendar scopes are not among them.
+var googleDeviceScopes = map[string]bool{
+	"openid":  true,
+	"email":   true,
+	"profile": true,
+	"https://www.googleapis.com/auth/userinfo.email":   true,
+	"https://www.googleapis.com/auth/userinfo.profile": true,
+	"https://www.googleapis.com/auth/drive.appdata":    true,
+	"https://www.googleapis.com/auth/drive.file":       true,
+	"https://www.googleapis.com/auth/youtube":          true,
+	"https://www.googleapis.com/auth/youtube.readonly": true,
+}
+
+// AuthenticateDevice runs the OAuth 2.0 device authorization grant: a code is shown to be entered
+// on another device, and the token endpoint is polled until access is granted or the code expires.
+// The OAuth client must be of the "TVs and Limited Input devices" type. Google does not grant
+// Calendar scopes with this flow, so it fails before any request for Google's endpoint.
+func (a *OAuthAuthenticator) AuthenticateDevice(ctx context.Context) error {
+	config, err := a.oauthConfig()
+	if err != nil {
+		return err
+	}
+	config.Endpoint.DeviceAuthURL = deviceAuthURL
+	if config.Endpoint.DeviceAuthURL == google.Endpoint.DeviceAuthURL {
+		for _, scope := range config.Scopes {
+			if !googleDeviceScopes[scope] {
+				return fmt.Errorf("the device flow cannot grant Calendar scopes (%s), use --no-browser instead", scope)
+			}
+		}
+	}
+
+	resp, err := config.DeviceAuth(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to start device authorization: %v", err)
+	}
+
+	fmt.Printf("Visit %s on any device and enter the code:\n\n    %s\n\n", resp.VerificationURI, resp.UserCode)
+	if resp.VerificationURIComplete != "" {
+		fmt.Printf("Or open this URL directly:\n%s\n\n", resp.VerificationURIComplete)
+	}
+	if !resp.Expiry.IsZero() {
+		fmt.Printf("Waiting for authorization (the code expires at %s)...\n", resp.Expiry.Local().Format("15:04:05"))
+	} else {
+		fmt.Println("Waiting for authorization...")
+	}
+
+	token, err := config.DeviceAccessToken(ctx, resp)
+	if err != nil {
+		return fmt.Errorf("unable to retrieve token: %v", err)
+	}
+
+	return a.saveToken(token)
+}
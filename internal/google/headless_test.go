@@ -0,0 +1,120 @@
+package google
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeDeviceServer is a device authorization server: the device endpoint hands out a code,
+// and the token endpoint keeps the flow pending for the first poll before granting access
+type fakeDeviceServer struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	scopes []string
+	polls  int
+}
+
+func newFakeDeviceServer(t *testing.T) *fakeDeviceServer {
+	t.Helper()
+	s := &fakeDeviceServer{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/device/code", s.device)
+	mux.HandleFunc("/token", s.token)
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *fakeDeviceServer) device(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.Form.Get("client_id") != "client-id" {
+		http.Error(w, `{"error":"invalid_client"}`, http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	s.scopes = strings.Fields(r.Form.Get("scope"))
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"device_code":"device1","user_code":"ABCD-EFGH","verification_uri":%q,"expires_in":60,"interval":1}`, s.URL+"/device")
+}
+
+func (s *fakeDeviceServer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil ||
+		r.Form.Get("grant_type") != "urn:ietf:params:oauth:grant-type:device_code" || r.Form.Get("device_code") != "device1" {
+		http.Error(w, `{"error":"invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	s.polls++
+	polls := s.polls
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if polls == 1 {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":"authorization_pending"}`)
+		return
+	}
+	fmt.Fprint(w, `{"access_token":"access-device1","refresh_token":"refresh-device1","token_type":"Bearer","expires_in":3600}`)
+}
+
+// useDeviceAuthURL replaces deviceAuthURL for the test
+func useDeviceAuthURL(t *testing.T, url string) {
+	t.Helper()
+	orig := deviceAuthURL
+	t.Cleanup(func() { deviceAuthURL = orig })
+	deviceAuthURL = url
+}
+
+func TestAuthenticateDevice(t *testing.T) {
+	srv := newFakeDeviceServer(t)
+	useDeviceAuthURL(t, srv.URL+"/device/code")
+	auth, store := newTestAuthenticator(t, &fakeAuthServer{Server: srv.Server})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := auth.AuthenticateDevice(ctx); err != nil {
+		t.Fatalf("AuthenticateDevice() error = %v", err)
+	}
+
+	token, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "access-device1" || token.RefreshToken != "refresh-device1" {
+		t.Errorf("saved token = %q/%q, want access-device1/refresh-device1", token.AccessToken, token.RefreshToken)
+	}
+	if srv.polls != 2 {
+		t.Errorf("token endpoint polled %d times, want 2", srv.polls)
+	}
+	if len(srv.scopes) != 1 || srv.scopes[0] != ReadOnlyScopes[0] {
+		t.Errorf("requested scopes = %q, want %q", srv.scopes, ReadOnlyScopes)
+	}
+}
+
+func TestAuthenticateDeviceGoogleScopes(t *testing.T) {
+	srv := newFakeDeviceServer(t)
+	auth, store := newTestAuthenticator(t, &fakeAuthServer{Server: srv.Server})
+
+	// Google's endpoint does not grant Calendar scopes, so no request is made
+	for _, scopes := range [][]string{ReadOnlyScopes, ReadWriteScopes, ManageScopes} {
+		auth.scopes = scopes
+		err := auth.AuthenticateDevice(context.Background())
+		if err == nil || !strings.Contains(err.Error(), "--no-browser") {
+			t.Errorf("AuthenticateDevice() with %q error = %v, want an error pointing to --no-browser", scopes, err)
+		}
+	}
+	if srv.scopes != nil || srv.polls != 0 {
+		t.Errorf("device server was called: scopes %q, %d polls", srv.scopes, srv.polls)
+	}
+	if _, err := os.Stat(store.path); !os.IsNotExist(err) {
+		t.Errorf("token file exists after a failed flow")
+	}
+}
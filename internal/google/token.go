@@ -0,0 +1,72 @@
+package google
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/oauth2"
+)
+
+// persistingTokenSource saves the tokens of the underlying source whenever it issues a new one,
+// so that refreshed access tokens and rotated refresh tokens survive the process.
+type persistingTokenSource struct {
+	src  oauth2.TokenSource
+	save func(*oauth2.Token) error
+
+	mu   sync.Mutex
+	last *oauth2.Token
+}
+
+func newPersistingTokenSource(src oauth2.TokenSource, initial *oauth2.Token, save func(*oauth2.Token) error) *persistingTokenSource {
+	return &persistingTokenSource{src: src, save: save, last: initial}
+}
+
+// Token returns the token of the underlying source, saving it first if it changed.
+// Failing to save is reported but does not fail the request, since the token itself is valid.
+func (s *persistingTokenSource) Token() (*oauth2.Token, error) {
+	token, err := s.src.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.last != nil && token.AccessToken == s.last.AccessToken && token.RefreshToken == s.last.RefreshToken {
+		return token, nil
+	}
+	if err := s.save(token); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: unable to save refreshed token: %v\n", err)
+	}
+	s.last = token
+	return token, nil
+}
+
+// writeFileAtomic replaces a file atomically: data is written to a temporary file in the
+// same directory, readable only by the user, which is then renamed over the old one.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp)
+
+	if err := f.Chmod(0o600); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,169 @@
+package google
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// newFakeTokenServer returns a token endpoint that issues the given access and refresh tokens,
+// and the number of requests it has received
+func newFakeTokenServer(t *testing.T, accessToken, refreshToken string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if err := r.ParseForm(); err != nil || r.Form.Get("grant_type") != "refresh_token" {
+			http.Error(w, `{"error":"invalid_request"}`, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":%q,"refresh_token":%q,"token_type":"Bearer","expires_in":3600}`, accessToken, refreshToken)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func testOAuthConfig(tokenURL string) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		Endpoint:     oauth2.Endpoint{TokenURL: tokenURL, AuthStyle: oauth2.AuthStyleInParams},
+	}
+}
+
+func TestPersistingTokenSourceSavesRefreshedToken(t *testing.T) {
+	srv, hits := newFakeTokenServer(t, "new-access", "new-refresh")
+	dir := t.TempDir()
+	store := &FileTokenStore{path: filepath.Join(dir, "token.json")}
+
+	expired := &oauth2.Token{AccessToken: "old-access", RefreshToken: "old-refresh", Expiry: time.Now().Add(-time.Hour)}
+	if err := store.Save(expired); err != nil {
+		t.Fatal(err)
+	}
+	// A token file with loose permissions must come back readable only by the user
+	if err := os.Chmod(store.path, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.Stat(store.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	src := newPersistingTokenSource(testOAuthConfig(srv.URL).TokenSource(ctx, expired), expired, store.Save)
+	token, err := src.Token()
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	if token.AccessToken != "new-access" {
+		t.Errorf("AccessToken = %q, want new-access", token.AccessToken)
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("token endpoint hit %d times, want 1", n)
+	}
+
+	saved, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.AccessToken != "new-access" || saved.RefreshToken != "new-refresh" {
+		t.Errorf("saved token = %q/%q, want new-access/new-refresh", saved.AccessToken, saved.RefreshToken)
+	}
+
+	after, err := os.Stat(store.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := after.Mode().Perm(); mode != 0o600 {
+		t.Errorf("token file mode = %o, want 600", mode)
+	}
+	// Replaced by a rename rather than rewritten in place
+	if os.SameFile(before, after) {
+		t.Error("token file was rewritten in place, want a new file renamed over it")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("directory has %d entries, want only the token file", len(entries))
+	}
+}
+
+func TestPersistingTokenSourceKeepsUnchangedToken(t *testing.T) {
+	srv, hits := newFakeTokenServer(t, "new-access", "new-refresh")
+	store := &FileTokenStore{path: filepath.Join(t.TempDir(), "token.json")}
+
+	valid := &oauth2.Token{AccessToken: "access", RefreshToken: "refresh", Expiry: time.Now().Add(time.Hour)}
+	if err := store.Save(valid); err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.Stat(store.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	src := newPersistingTokenSource(testOAuthConfig(srv.URL).TokenSource(ctx, valid), valid, store.Save)
+	for i := 0; i < 3; i++ {
+		token, err := src.Token()
+		if err != nil {
+			t.Fatalf("Token() error = %v", err)
+		}
+		if token.AccessToken != "access" {
+			t.Errorf("AccessToken = %q, want access", token.AccessToken)
+		}
+	}
+	if n := hits.Load(); n != 0 {
+		t.Errorf("token endpoint hit %d times, want 0", n)
+	}
+
+	after, err := os.Stat(store.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !os.SameFile(before, after) || !after.ModTime().Equal(before.ModTime()) {
+		t.Error("token file was rewritten although the token did not change")
+	}
+}
+
+func TestFileTokenStoreSaveError(t *testing.T) {
+	dir := t.TempDir()
+	token := &oauth2.Token{AccessToken: "access", RefreshToken: "refresh"}
+
+	t.Run("missing directory", func(t *testing.T) {
+		store := &FileTokenStore{path: filepath.Join(dir, "missing", "token.json")}
+		if err := store.Save(token); err == nil {
+			t.Error("Save() error = nil, want an error")
+		}
+	})
+
+	t.Run("rename fails", func(t *testing.T) {
+		// A non-empty directory where the token file should be cannot be renamed over
+		path := filepath.Join(dir, "token.json")
+		if err := os.MkdirAll(filepath.Join(path, "sub"), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		store := &FileTokenStore{path: path}
+		if err := store.Save(token); err == nil {
+			t.Error("Save() error = nil, want an error")
+		}
+		// The temporary file is cleaned up
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 {
+			t.Errorf("directory has %d entries, want only %s", len(entries), filepath.Base(path))
+		}
+	})
+}
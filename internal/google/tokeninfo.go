@@ -0,0 +1,157 @@
+package google
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// Google OAuth endpoints to inspect and revoke tokens
+const (
+	tokenInfoURL = "https://oauth2.googleapis.com/tokeninfo"
+	revokeURL    = "https://oauth2.googleapis.com/revoke"
+)
+
+// TokenInfo describes an access token as reported by Google
+type TokenInfo struct {
+	// Email is only known when the token has the email scope
+	Email  string
+	Scopes []string
+	Expiry time.Time
+}
+
+// Token returns a valid token, refreshing and saving it if it has expired
+func (a *OAuthAuthenticator) Token(ctx context.Context) (*oauth2.Token, error) {
+	config, err := a.oauthConfig()
+	if err != nil {
+		return nil, err
+	}
+	token, err := a.store.Load()
+	if err != nil {
+		return nil, err
+	}
+	src := newPersistingTokenSource(config.TokenSource(ctx, token), token, a.store.Save)
+	return src.Token()
+}
+
+// Token returns an access token of the service account, checking that its key is valid
+func (a *ServiceAccountAuthenticator) Token(ctx context.Context, scopes ...string) (*oauth2.Token, error) {
+	b, err := os.ReadFile(a.credentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read service account file: %v", err)
+	}
+	config, err := google.JWTConfigFromJSON(b, scopes...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse service account file: %v", err)
+	}
+	return config.TokenSource(ctx).Token()
+}
+
+// Email returns the email address of the service account
+func (a *ServiceAccountAuthenticator) Email() (string, error) {
+	b, err := os.ReadFile(a.credentialsFile)
+	if err != nil {
+		return "", fmt.Errorf("unable to read service account file: %v", err)
+	}
+	var key struct {
+		ClientEmail string `json:"client_email"`
+	}
+	if err := json.Unmarshal(b, &key); err != nil {
+		return "", fmt.Errorf("unable to parse service account file: %v", err)
+	}
+	return key.ClientEmail, nil
+}
+
+// FetchTokenInfo asks Google for the account, scopes and expiry of an access token
+func FetchTokenInfo(ctx context.Context, accessToken string) (*TokenInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenInfoURL+"?access_token="+url.QueryEscape(accessToken), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Email            string `json:"email"`
+		Scope            string `json:"scope"`
+		Exp              string `json:"exp"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("invalid token info response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if body.ErrorDescription != "" {
+			return nil, fmt.Errorf("token rejected: %s", body.ErrorDescription)
+		}
+		return nil, fmt.Errorf("token rejected: %s", resp.Status)
+	}
+
+	info := &TokenInfo{Email: body.Email, Scopes: strings.Fields(body.Scope)}
+	if exp, err := strconv.ParseInt(body.Exp, 10, 64); err == nil {
+		info.Expiry = time.Unix(exp, 0)
+	}
+	return info, nil
+}
+
+// Revoke revokes the token at Google and deletes it from the token store.
+// A token Google no longer knows is treated as revoked.
+func (a *OAuthAuthenticator) Revoke(ctx context.Context) error {
+	token, err := a.store.Load()
+	if err != nil {
+		return fmt.Errorf("unable to read token: %w", err)
+	}
+
+	// Revoking the refresh token also revokes the access tokens issued from it
+	value := token.RefreshToken
+	if value == "" {
+		value = token.AccessToken
+	}
+	if err := revokeToken(ctx, value); err != nil {
+		return err
+	}
+
+	if err := a.store.Delete(); err != nil {
+		return fmt.Errorf("unable to delete token: %v", err)
+	}
+	return nil
+}
+
+func revokeToken(ctx context.Context, token string) error {
+	form := url.Values{"token": {token}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, revokeURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to revoke token: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	var body struct {
+		Error string `json:"error"`
+	}
+	json.NewDecoder(resp.Body).Decode(&body)
+	if body.Error == "invalid_token" {
+		return nil
+	}
+	return fmt.Errorf("unable to revoke token: %s", resp.Status)
+}
@@ -0,0 +1,291 @@
+package google
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/zalando/go-keyring"
+	"golang.org/x/oauth2"
+)
+
+// Kinds of token stores, as set with the token_store config key
+const (
+	TokenStoreFile      = "file"
+	TokenStoreKeyring   = "keyring"
+	TokenStoreEncrypted = "encrypted"
+)
+
+// TokenStoreKinds lists the kinds of token stores
+var TokenStoreKinds = []string{TokenStoreFile, TokenStoreKeyring, TokenStoreEncrypted}
+
+// TokenPassphraseEnv is the environment variable holding the passphrase of the encrypted token store
+const TokenPassphraseEnv = "GCAL_TOKEN_PASSPHRASE"
+
+// keyringService is the service name tokens are stored under in the OS keyring
+const keyringService = "gcal"
+
+// ErrTokenNotFound is returned by TokenStore.Load when no token has been saved
+var ErrTokenNotFound = errors.New("token not found")
+
+// TokenStore persists the OAuth token of the user
+type TokenStore interface {
+	// Load returns the saved token, or an error wrapping ErrTokenNotFound if there is none
+	Load() (*oauth2.Token, error)
+	Save(token *oauth2.Token) error
+	// Delete removes the saved token; deleting a missing token is not an error
+	Delete() error
+	// String describes where the token is stored, for messages
+	String() string
+}
+
+// NewTokenStore returns the token store of the given kind.
+// path is the user_credentials file used by the file-based stores. The keyring store keeps the
+// token under the client ID of the client secret file credentialsFile, which stays the same
+// when the config files are moved.
+func NewTokenStore(kind, path, credentialsFile string) (TokenStore, error) {
+	switch kind {
+	case TokenStoreFile, "":
+		return &FileTokenStore{path: path}, nil
+	case TokenStoreKeyring:
+		key, err := clientID(credentialsFile)
+		if err != nil {
+			return nil, err
+		}
+		return &KeyringTokenStore{key: key}, nil
+	case TokenStoreEncrypted:
+		return &EncryptedFileTokenStore{path: path}, nil
+	default:
+		return nil, fmt.Errorf("invalid token_store: %s (valid: file, keyring, encrypted)", kind)
+	}
+}
+
+// FileTokenStore stores the token as plain JSON in a file readable only by the user
+type FileTokenStore struct {
+	path string
+}
+
+// Path returns the path of the token file
+func (s *FileTokenStore) Path() string {
+	return s.path
+}
+
+func (s *FileTokenStore) Load() (*oauth2.Token, error) {
+	b, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrTokenNotFound, s.path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	token := &oauth2.Token{}
+	if err := json.Unmarshal(b, token); err != nil {
+		return nil, fmt.Errorf("invalid token file %s: %v", s.path, err)
+	}
+	return token, nil
+}
+
+func (s *FileTokenStore) Save(token *oauth2.Token) error {
+	b, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, b)
+}
+
+func (s *FileTokenStore) Delete() error {
+	if err := os.Remove(s.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *FileTokenStore) String() string {
+	return "file " + s.path
+}
+
+// KeyringTokenStore stores the token in the OS keyring: the Secret Service on Linux,
+// the Keychain on macOS and the Credential Manager on Windows
+type KeyringTokenStore struct {
+	key string
+}
+
+func (s *KeyringTokenStore) Load() (*oauth2.Token, error) {
+	secret, err := keyring.Get(keyringService, s.key)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return nil, fmt.Errorf("%w: keyring entry %s", ErrTokenNotFound, s.key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read keyring: %v", err)
+	}
+	token := &oauth2.Token{}
+	if err := json.Unmarshal([]byte(secret), token); err != nil {
+		return nil, fmt.Errorf("invalid token in keyring: %v", err)
+	}
+	return token, nil
+}
+
+func (s *KeyringTokenStore) Save(token *oauth2.Token) error {
+	b, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	if err := keyring.Set(keyringService, s.key, string(b)); err != nil {
+		return fmt.Errorf("unable to write keyring: %v", err)
+	}
+	return nil
+}
+
+func (s *KeyringTokenStore) Delete() error {
+	if err := keyring.Delete(keyringService, s.key); err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		return fmt.Errorf("unable to delete keyring entry: %v", err)
+	}
+	return nil
+}
+
+func (s *KeyringTokenStore) String() string {
+	return "keyring entry " + s.key
+}
+
+// EncryptedFileTokenStore stores the token in a file encrypted with AES-256-GCM,
+// using a key derived with PBKDF2 from the passphrase in TokenPassphraseEnv.
+// The salt of the file is kept when the token is saved again, so that the key is derived
+// once per process.
+type EncryptedFileTokenStore struct {
+	path string
+	salt []byte
+}
+
+// encryptedToken is the content of an encrypted token file
+type encryptedToken struct {
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+const (
+	encryptedTokenKDF        = "pbkdf2-sha256"
+	encryptedTokenIterations = 600000
+	// maxEncryptedTokenIterations bounds the iterations read from a file, so that a corrupted
+	// or tampered file cannot make key derivation run for hours
+	maxEncryptedTokenIterations = 10 * encryptedTokenIterations
+)
+
+func (s *EncryptedFileTokenStore) Load() (*oauth2.Token, error) {
+	b, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrTokenNotFound, s.path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var enc encryptedToken
+	if err := json.Unmarshal(b, &enc); err != nil || enc.KDF != encryptedTokenKDF ||
+		enc.Iterations < 1 || enc.Iterations > maxEncryptedTokenIterations {
+		return nil, fmt.Errorf("invalid encrypted token file %s", s.path)
+	}
+
+	aead, err := tokenCipher(enc.Salt, enc.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	if len(enc.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("invalid encrypted token file %s", s.path)
+	}
+	plain, err := aead.Open(nil, enc.Nonce, enc.Ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt %s: wrong passphrase or corrupted file", s.path)
+	}
+	if enc.Iterations == encryptedTokenIterations {
+		s.salt = enc.Salt
+	}
+	token := &oauth2.Token{}
+	if err := json.Unmarshal(plain, token); err != nil {
+		return nil, fmt.Errorf("invalid token in %s: %v", s.path, err)
+	}
+	return token, nil
+}
+
+func (s *EncryptedFileTokenStore) Save(token *oauth2.Token) error {
+	plain, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+
+	if s.salt == nil {
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return err
+		}
+		s.salt = salt
+	}
+	enc := encryptedToken{KDF: encryptedTokenKDF, Iterations: encryptedTokenIterations, Salt: s.salt}
+	aead, err := tokenCipher(enc.Salt, enc.Iterations)
+	if err != nil {
+		return err
+	}
+	enc.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(enc.Nonce); err != nil {
+		return err
+	}
+	enc.Ciphertext = aead.Seal(nil, enc.Nonce, plain, nil)
+
+	b, err := json.Marshal(enc)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, b)
+}
+
+func (s *EncryptedFileTokenStore) Delete() error {
+	if err := os.Remove(s.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *EncryptedFileTokenStore) String() string {
+	return "encrypted file " + s.path
+}
+
+// tokenKeys caches the keys derived by tokenCipher, by passphrase, salt and iterations
+var tokenKeys = struct {
+	sync.Mutex
+	m map[[sha256.Size]byte][]byte
+}{m: make(map[[sha256.Size]byte][]byte)}
+
+// tokenCipher derives the key of the encrypted token store from the passphrase.
+// Keys are cached for the life of the process, since deriving one is deliberately slow.
+func tokenCipher(salt []byte, iterations int) (cipher.AEAD, error) {
+	passphrase := os.Getenv(TokenPassphraseEnv)
+	if passphrase == "" {
+		return nil, fmt.Errorf("%s must be set to use the encrypted token store", TokenPassphraseEnv)
+	}
+
+	id := sha256.Sum256(fmt.Appendf(nil, "%d\x00%x\x00%s", iterations, salt, passphrase))
+	tokenKeys.Lock()
+	defer tokenKeys.Unlock()
+	key, ok := tokenKeys.m[id]
+	if !ok {
+		var err error
+		key, err = pbkdf2.Key(sha256.New, passphrase, salt, iterations, 32)
+		if err != nil {
+			return nil, err
+		}
+		tokenKeys.m[id] = key
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
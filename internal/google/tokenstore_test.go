@@ -0,0 +1,150 @@
+package google
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zalando/go-keyring"
+	"golang.org/x/oauth2"
+)
+
+func writeClientSecret(t *testing.T, dir, clientID string) string {
+	t.Helper()
+	path := filepath.Join(dir, "client_secret.json")
+	secret := `{"installed":{"client_id":"` + clientID + `","client_secret":"secret","auth_uri":"https://accounts.example.com/auth","token_uri":"https://oauth2.example.com/token","redirect_uris":["http://localhost"]}}`
+	if err := os.WriteFile(path, []byte(secret), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestEncryptedFileTokenStore(t *testing.T) {
+	t.Setenv(TokenPassphraseEnv, "correct horse battery staple")
+	path := filepath.Join(t.TempDir(), "token.json")
+	token := &oauth2.Token{AccessToken: "access", RefreshToken: "refresh"}
+
+	tokenKeys.Lock()
+	cached := len(tokenKeys.m)
+	tokenKeys.Unlock()
+
+	store := &EncryptedFileTokenStore{path: path}
+	if err := store.Save(token); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// Another store, as in the next run, loads the token and saves a refreshed one
+	store = &EncryptedFileTokenStore{path: path}
+	got, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got.AccessToken != "access" || got.RefreshToken != "refresh" {
+		t.Errorf("Load() = %q/%q, want access/refresh", got.AccessToken, got.RefreshToken)
+	}
+	token.AccessToken = "refreshed"
+	if err := store.Save(token); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if got, err := store.Load(); err != nil || got.AccessToken != "refreshed" {
+		t.Errorf("Load() = %v, %v, want the refreshed token", got, err)
+	}
+
+	// The salt is kept, so the key was derived only once
+	tokenKeys.Lock()
+	derived := len(tokenKeys.m) - cached
+	tokenKeys.Unlock()
+	if derived != 1 {
+		t.Errorf("derived %d keys, want 1", derived)
+	}
+
+	t.Setenv(TokenPassphraseEnv, "wrong")
+	if _, err := (&EncryptedFileTokenStore{path: path}).Load(); err == nil {
+		t.Error("Load() with a wrong passphrase error = nil, want an error")
+	}
+	t.Setenv(TokenPassphraseEnv, "")
+	if _, err := (&EncryptedFileTokenStore{path: path}).Load(); err == nil || errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("Load() without a passphrase error = %v, want an error other than ErrTokenNotFound", err)
+	}
+}
+
+func TestEncryptedFileTokenStoreIterations(t *testing.T) {
+	t.Setenv(TokenPassphraseEnv, "correct horse battery staple")
+	path := filepath.Join(t.TempDir(), "token.json")
+	if err := (&EncryptedFileTokenStore{path: path}).Save(&oauth2.Token{AccessToken: "access"}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var enc map[string]any
+	if err := json.Unmarshal(b, &enc); err != nil {
+		t.Fatal(err)
+	}
+
+	// A tampered iteration count is rejected before any key is derived
+	for _, iterations := range []int{0, -1, maxEncryptedTokenIterations + 1, 2000000000} {
+		enc["iterations"] = iterations
+		b, _ := json.Marshal(enc)
+		if err := os.WriteFile(path, b, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		start := time.Now()
+		_, err := (&EncryptedFileTokenStore{path: path}).Load()
+		if err == nil || !strings.Contains(err.Error(), "invalid encrypted token file") {
+			t.Errorf("Load() with %d iterations error = %v, want an invalid file error", iterations, err)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("Load() with %d iterations took %s", iterations, elapsed)
+		}
+	}
+}
+
+func TestKeyringTokenStore(t *testing.T) {
+	keyring.MockInit()
+	dir := t.TempDir()
+	credentials := writeClientSecret(t, dir, "123.apps.googleusercontent.com")
+
+	store, err := NewTokenStore(TokenStoreKeyring, filepath.Join(dir, "token.json"), credentials)
+	if err != nil {
+		t.Fatalf("NewTokenStore() error = %v", err)
+	}
+	if _, err := store.Load(); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("Load() before Save error = %v, want ErrTokenNotFound", err)
+	}
+	if err := store.Save(&oauth2.Token{AccessToken: "access", RefreshToken: "refresh"}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// The token is found by client ID, wherever the config files are
+	moved := filepath.Join(t.TempDir(), "elsewhere.json")
+	if err := os.Rename(credentials, moved); err != nil {
+		t.Fatal(err)
+	}
+	store, err = NewTokenStore(TokenStoreKeyring, filepath.Join(dir, "other.json"), moved)
+	if err != nil {
+		t.Fatalf("NewTokenStore() error = %v", err)
+	}
+	got, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got.RefreshToken != "refresh" {
+		t.Errorf("RefreshToken = %q, want refresh", got.RefreshToken)
+	}
+	if secret, err := keyring.Get(keyringService, "123.apps.googleusercontent.com"); err != nil || secret == "" {
+		t.Errorf("keyring entry for the client ID = %q, %v", secret, err)
+	}
+
+	if err := store.Delete(); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := store.Delete(); err != nil {
+		t.Errorf("Delete() of a missing token error = %v, want nil", err)
+	}
+}
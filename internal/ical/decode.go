@@ -0,0 +1,514 @@
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// property is an unfolded content line
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+	// line is the line number the property starts at
+	line int
+}
+
+// param returns the value of a parameter, or "" if it is not set
+func (p *property) param(name string) string {
+	return p.params[name]
+}
+
+// component is a BEGIN/END block with its properties and nested components
+type component struct {
+	name       string
+	props      []*property
+	components []*component
+	line       int
+}
+
+// prop returns the first property called name, or nil
+func (c *component) prop(name string) *property {
+	for _, p := range c.props {
+		if p.name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// value returns the value of the first property called name, or ""
+func (c *component) value(name string) string {
+	if p := c.prop(name); p != nil {
+		return p.value
+	}
+	return ""
+}
+
+// Decode parses an iCalendar stream and returns its VEVENTs as events for Events.Import.
+// Occurrences that override a recurring event (VEVENTs with a RECURRENCE-ID) are returned
+// with OriginalStartTime set and the ICalUID of their series.
+// Times with a TZID unknown to the system time zone database are converted with the
+// matching VTIMEZONE of the stream, which is mapped to an IANA time zone when possible;
+// recurring events in a VTIMEZONE that cannot be mapped are rejected, since they could not be
+// repeated across daylight saving time changes. Floating times are taken as local time.
+func Decode(r io.Reader) ([]*calendar.Event, error) {
+	props, err := readProperties(r)
+	if err != nil {
+		return nil, err
+	}
+	roots, err := buildComponents(props)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []*calendar.Event
+	for _, root := range roots {
+		if root.name != "VCALENDAR" {
+			return nil, fmt.Errorf("line %d: expected VCALENDAR, got %s", root.line, root.name)
+		}
+		zones := make(map[string]*vtimezone)
+		for _, c := range root.components {
+			if c.name == "VTIMEZONE" {
+				z, err := parseTimeZone(c)
+				if err != nil {
+					return nil, err
+				}
+				zones[z.id] = z
+			}
+		}
+		d := &decoder{zones: zones}
+		for _, c := range root.components {
+			if c.name != "VEVENT" {
+				continue
+			}
+			ev, err := d.event(c)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, ev)
+		}
+	}
+	return events, nil
+}
+
+// readProperties unfolds content lines and splits them into properties
+func readProperties(r io.Reader) ([]*property, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var props []*property
+	var current strings.Builder
+	start, n := 0, 0
+	flush := func() error {
+		if current.Len() == 0 {
+			return nil
+		}
+		p, err := parseProperty(current.String())
+		if err != nil {
+			return fmt.Errorf("line %d: %w", start, err)
+		}
+		p.line = start
+		props = append(props, p)
+		current.Reset()
+		return nil
+	}
+
+	for sc.Scan() {
+		n++
+		line := strings.TrimSuffix(sc.Text(), "\r")
+		if n == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			current.WriteString(line[1:])
+			continue
+		}
+		if err := flush(); err != nil {
+			return nil, err
+		}
+		if line != "" {
+			current.WriteString(line)
+			start = n
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return props, nil
+}
+
+// parseProperty parses a content line of the form NAME;PARAM=VALUE,...:VALUE
+func parseProperty(s string) (*property, error) {
+	p := &property{params: make(map[string]string)}
+	i := strings.IndexAny(s, ";:")
+	if i <= 0 {
+		return nil, fmt.Errorf("invalid content line: %q", s)
+	}
+	p.name = strings.ToUpper(s[:i])
+
+	for s[i] == ';' {
+		s = s[i+1:]
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 {
+			return nil, fmt.Errorf("invalid parameter in %s", p.name)
+		}
+		name := strings.ToUpper(s[:eq])
+		s = s[eq+1:]
+
+		// Read the (possibly quoted, comma separated) values up to the next ';' or ':'
+		var value strings.Builder
+		quoted := false
+		i = 0
+		for ; i < len(s); i++ {
+			c := s[i]
+			if c == '"' {
+				quoted = !quoted
+				continue
+			}
+			if !quoted && (c == ';' || c == ':') {
+				break
+			}
+			value.WriteByte(c)
+		}
+		if i == len(s) {
+			return nil, fmt.Errorf("missing value in %s", p.name)
+		}
+		p.params[name] = value.String()
+	}
+
+	p.value = s[i+1:]
+	return p, nil
+}
+
+// buildComponents nests properties into components
+func buildComponents(props []*property) ([]*component, error) {
+	var roots []*component
+	var stack []*component
+	for _, p := range props {
+		switch p.name {
+		case "BEGIN":
+			c := &component{name: strings.ToUpper(p.value), line: p.line}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.components = append(parent.components, c)
+			} else {
+				roots = append(roots, c)
+			}
+			stack = append(stack, c)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].name != strings.ToUpper(p.value) {
+				return nil, fmt.Errorf("line %d: unexpected END:%s", p.line, p.value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: property %s outside of a component", p.line, p.name)
+			}
+			c := stack[len(stack)-1]
+			c.props = append(c.props, p)
+		}
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("line %d: missing END:%s", stack[len(stack)-1].line, stack[len(stack)-1].name)
+	}
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("no VCALENDAR found")
+	}
+	return roots, nil
+}
+
+// decoder converts the VEVENTs of a VCALENDAR using its time zones
+type decoder struct {
+	zones map[string]*vtimezone
+}
+
+var statusNames = map[string]string{
+	"CONFIRMED": "confirmed",
+	"TENTATIVE": "tentative",
+	"CANCELLED": "cancelled",
+}
+
+var responseStatuses = map[string]string{
+	"NEEDS-ACTION": "needsAction",
+	"ACCEPTED":     "accepted",
+	"DECLINED":     "declined",
+	"TENTATIVE":    "tentative",
+}
+
+func (d *decoder) event(c *component) (*calendar.Event, error) {
+	ev := &calendar.Event{
+		ICalUID:     c.value("UID"),
+		Summary:     unescapeText(c.value("SUMMARY")),
+		Description: unescapeText(c.value("DESCRIPTION")),
+		Location:    unescapeText(c.value("LOCATION")),
+	}
+	if ev.ICalUID == "" {
+		return nil, fmt.Errorf("line %d: VEVENT has no UID", c.line)
+	}
+
+	dtstart := c.prop("DTSTART")
+	if dtstart == nil {
+		return nil, fmt.Errorf("line %d: VEVENT %s has no DTSTART", c.line, ev.ICalUID)
+	}
+	start, startTime, allDay, err := d.dateTime(dtstart)
+	if err != nil {
+		return nil, err
+	}
+	ev.Start = start
+
+	switch {
+	case c.prop("DTEND") != nil:
+		ev.End, _, _, err = d.dateTime(c.prop("DTEND"))
+		if err != nil {
+			return nil, err
+		}
+	case c.prop("DURATION") != nil:
+		p := c.prop("DURATION")
+		dur, err := parseDuration(p.value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", p.line, err)
+		}
+		ev.End = endAfter(start, startTime, allDay, dur)
+	case allDay:
+		ev.End = endAfter(start, startTime, allDay, 24*time.Hour)
+	default:
+		ev.End = endAfter(start, startTime, allDay, 0)
+	}
+
+	if p := c.prop("RECURRENCE-ID"); p != nil {
+		ev.OriginalStartTime, _, _, err = d.dateTime(p)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, p := range c.props {
+		switch p.name {
+		case "RRULE":
+			ev.Recurrence = append(ev.Recurrence, "RRULE:"+p.value)
+		case "EXDATE", "RDATE":
+			line, err := d.recurrenceDates(p)
+			if err != nil {
+				return nil, err
+			}
+			ev.Recurrence = append(ev.Recurrence, line)
+		case "ATTENDEE":
+			ev.Attendees = append(ev.Attendees, attendee(p))
+		}
+	}
+	// Recurring events need a time zone to expand in. UTC is right for UTC times, but would
+	// move the occurrences of a zone with daylight saving time by an hour for half of the year.
+	if len(ev.Recurrence) > 0 && !allDay && ev.Start.TimeZone == "" {
+		if tzid := dtstart.param("TZID"); tzid != "" {
+			return nil, fmt.Errorf("line %d: recurring VEVENT %s uses time zone %q, which has no known IANA equivalent", dtstart.line, ev.ICalUID, tzid)
+		}
+		ev.Start.TimeZone, ev.End.TimeZone = "UTC", "UTC"
+	}
+
+	if status, ok := statusNames[strings.ToUpper(c.value("STATUS"))]; ok {
+		ev.Status = status
+	}
+	if strings.EqualFold(c.value("TRANSP"), "TRANSPARENT") {
+		ev.Transparency = "transparent"
+	}
+	if p := c.prop("ORGANIZER"); p != nil {
+		ev.Organizer = &calendar.EventOrganizer{Email: mailAddress(p.value), DisplayName: p.param("CN")}
+	}
+	if url := c.value("URL"); url != "" {
+		ev.Source = &calendar.EventSource{Url: url, Title: ev.Summary}
+	}
+	if seq := c.value("SEQUENCE"); seq != "" {
+		fmt.Sscanf(seq, "%d", &ev.Sequence)
+	}
+	return ev, nil
+}
+
+func attendee(p *property) *calendar.EventAttendee {
+	a := &calendar.EventAttendee{
+		Email:       mailAddress(p.value),
+		DisplayName: p.param("CN"),
+		Optional:    strings.EqualFold(p.param("ROLE"), "OPT-PARTICIPANT"),
+	}
+	if status, ok := responseStatuses[strings.ToUpper(p.param("PARTSTAT"))]; ok {
+		a.ResponseStatus = status
+	} else {
+		a.ResponseStatus = "needsAction"
+	}
+	return a
+}
+
+func mailAddress(s string) string {
+	if len(s) >= 7 && strings.EqualFold(s[:7], "mailto:") {
+		return s[7:]
+	}
+	return s
+}
+
+// dateTime converts a DATE or DATE-TIME property.
+// It also returns the time as an instant and whether it is a DATE.
+func (d *decoder) dateTime(p *property) (*calendar.EventDateTime, time.Time, bool, error) {
+	if strings.EqualFold(p.param("VALUE"), "DATE") || len(p.value) == len(dateLayout) {
+		t, err := time.ParseInLocation(dateLayout, p.value, time.Local)
+		if err != nil {
+			return nil, time.Time{}, false, fmt.Errorf("line %d: invalid date %q", p.line, p.value)
+		}
+		return &calendar.EventDateTime{Date: t.Format("2006-01-02")}, t, true, nil
+	}
+
+	t, tzid, err := d.parseDateTime(p.value, p.param("TZID"))
+	if err != nil {
+		return nil, time.Time{}, false, fmt.Errorf("line %d: %w", p.line, err)
+	}
+	return &calendar.EventDateTime{DateTime: t.Format(time.RFC3339), TimeZone: tzid}, t, false, nil
+}
+
+// parseDateTime parses a DATE-TIME value, returning the instant and the IANA time zone it is in,
+// which is empty for UTC, floating times and VTIMEZONEs without a known IANA equivalent
+func (d *decoder) parseDateTime(value, tzid string) (time.Time, string, error) {
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(utcLayout, value)
+		if err != nil {
+			return time.Time{}, "", fmt.Errorf("invalid date-time %q", value)
+		}
+		return t, "", nil
+	}
+
+	wall, err := time.Parse(localLayout, value)
+	if err != nil {
+		return time.Time{}, "", fmt.Errorf("invalid date-time %q", value)
+	}
+	if tzid == "" {
+		return inLocation(wall, time.Local), "", nil
+	}
+	if loc, err := time.LoadLocation(strings.TrimPrefix(tzid, "/")); err == nil {
+		return inLocation(wall, loc), loc.String(), nil
+	}
+	z, ok := d.zones[tzid]
+	if !ok {
+		return time.Time{}, "", fmt.Errorf("unknown time zone %q", tzid)
+	}
+	if z.location != nil {
+		return inLocation(wall, z.location), z.location.String(), nil
+	}
+	return wall.Add(-time.Duration(z.offset(wall)) * time.Second), "", nil
+}
+
+// inLocation interprets the wall clock of a UTC time in loc
+func inLocation(wall time.Time, loc *time.Location) time.Time {
+	return time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), 0, loc)
+}
+
+// recurrenceDates converts an EXDATE or RDATE property to a recurrence line Google accepts.
+// Times in zones unknown to the system are converted to UTC.
+func (d *decoder) recurrenceDates(p *property) (string, error) {
+	tzid := p.param("TZID")
+	if strings.EqualFold(p.param("VALUE"), "DATE") {
+		return p.name + ";VALUE=DATE:" + p.value, nil
+	}
+	if strings.EqualFold(p.param("VALUE"), "PERIOD") {
+		return p.name + ";VALUE=PERIOD:" + p.value, nil
+	}
+	if tzid != "" {
+		if loc, err := time.LoadLocation(strings.TrimPrefix(tzid, "/")); err == nil {
+			return p.name + ";TZID=" + loc.String() + ":" + p.value, nil
+		}
+		if z, ok := d.zones[tzid]; ok && z.location != nil {
+			return p.name + ";TZID=" + z.location.String() + ":" + p.value, nil
+		}
+	}
+
+	values := strings.Split(p.value, ",")
+	for i, v := range values {
+		t, _, err := d.parseDateTime(v, tzid)
+		if err != nil {
+			return "", fmt.Errorf("line %d: %w", p.line, err)
+		}
+		values[i] = t.UTC().Format(utcLayout)
+	}
+	return p.name + ":" + strings.Join(values, ","), nil
+}
+
+// endAfter returns the end of an event starting at start and lasting d
+func endAfter(start *calendar.EventDateTime, t time.Time, allDay bool, d time.Duration) *calendar.EventDateTime {
+	if allDay {
+		days := int(d / (24 * time.Hour))
+		if days < 1 {
+			days = 1
+		}
+		return &calendar.EventDateTime{Date: t.AddDate(0, 0, days).Format("2006-01-02")}
+	}
+	end := t.Add(d)
+	if start.TimeZone != "" {
+		if loc, err := time.LoadLocation(start.TimeZone); err == nil {
+			end = end.In(loc)
+		}
+	}
+	return &calendar.EventDateTime{DateTime: end.Format(time.RFC3339), TimeZone: start.TimeZone}
+}
+
+// parseDuration parses a DURATION value such as P1D, PT1H30M or -P1W
+func parseDuration(s string) (time.Duration, error) {
+	orig := s
+	sign := time.Duration(1)
+	switch {
+	case strings.HasPrefix(s, "-"):
+		sign = -1
+		s = s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+	if !strings.HasPrefix(s, "P") || len(s) < 3 {
+		return 0, fmt.Errorf("invalid duration %q", orig)
+	}
+	s = s[1:]
+
+	var d time.Duration
+	inTime := false
+	n := -1
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			if n < 0 {
+				n = 0
+			}
+			n = n*10 + int(c-'0')
+			continue
+		case c == 'T' && !inTime && n < 0:
+			inTime = true
+			continue
+		}
+		if n < 0 {
+			return 0, fmt.Errorf("invalid duration %q", orig)
+		}
+		unit := map[rune]time.Duration{'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour}
+		if inTime {
+			unit = map[rune]time.Duration{'H': time.Hour, 'M': time.Minute, 'S': time.Second}
+		}
+		u, ok := unit[c]
+		if !ok {
+			return 0, fmt.Errorf("invalid duration %q", orig)
+		}
+		d += time.Duration(n) * u
+		n = -1
+	}
+	if n >= 0 {
+		return 0, fmt.Errorf("invalid duration %q", orig)
+	}
+	return sign * d, nil
+}
+
+// textUnescaper reverses the escaping of TEXT values
+var textUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+func unescapeText(s string) string {
+	return textUnescaper.Replace(s)
+}
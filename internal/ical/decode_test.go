@@ -0,0 +1,101 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+)
+
+// dstTimeZone is a VTIMEZONE with central European daylight saving time rules under the name tzid
+func dstTimeZone(tzid, extra string) string {
+	return "BEGIN:VTIMEZONE\r\nTZID:" + tzid + "\r\n" + extra +
+		"BEGIN:STANDARD\r\nDTSTART:19701025T030000\r\nRRULE:FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU\r\nTZOFFSETFROM:+0200\r\nTZOFFSETTO:+0100\r\nEND:STANDARD\r\n" +
+		"BEGIN:DAYLIGHT\r\nDTSTART:19700329T020000\r\nRRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU\r\nTZOFFSETFROM:+0100\r\nTZOFFSETTO:+0200\r\nEND:DAYLIGHT\r\n" +
+		"END:VTIMEZONE\r\n"
+}
+
+// weeklyEvent is a weekly VEVENT starting on 2024-01-15 at 10:00 in tzid, with an EXDATE
+func weeklyEvent(tzid string, recurring bool) string {
+	ev := "BEGIN:VEVENT\r\nUID:weekly@example.com\r\n" +
+		"DTSTART;TZID=\"" + tzid + "\":20240115T100000\r\nDTEND;TZID=\"" + tzid + "\":20240115T110000\r\n"
+	if recurring {
+		ev += "RRULE:FREQ=WEEKLY;COUNT=10\r\nEXDATE;TZID=\"" + tzid + "\":20240122T100000\r\n"
+	}
+	return ev + "SUMMARY:Weekly\r\nEND:VEVENT\r\n"
+}
+
+func calendarOf(parts ...string) string {
+	return "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//test//EN\r\n" + strings.Join(parts, "") + "END:VCALENDAR\r\n"
+}
+
+func TestDecodeTimeZoneMapping(t *testing.T) {
+	tests := []struct {
+		name     string
+		tzid     string
+		zone     string
+		wantZone string
+		wantTime string
+	}{
+		{"windows name", "W. Europe Standard Time", dstTimeZone("W. Europe Standard Time", ""),
+			"Europe/Berlin", "2024-01-15T10:00:00+01:00"},
+		{"X-LIC-LOCATION", "Custom Berlin", dstTimeZone("Custom Berlin", "X-LIC-LOCATION:Europe/Berlin\r\n"),
+			"Europe/Berlin", "2024-01-15T10:00:00+01:00"},
+		{"IANA name in a path", "/mozilla.org/20050126_1/Europe/Berlin", dstTimeZone("/mozilla.org/20050126_1/Europe/Berlin", ""),
+			"Europe/Berlin", "2024-01-15T10:00:00+01:00"},
+		{"fixed whole-hour offset", "Custom JST",
+			"BEGIN:VTIMEZONE\r\nTZID:Custom JST\r\nBEGIN:STANDARD\r\nDTSTART:19700101T000000\r\nTZOFFSETFROM:+0900\r\nTZOFFSETTO:+0900\r\nEND:STANDARD\r\nEND:VTIMEZONE\r\n",
+			"Etc/GMT-9", "2024-01-15T10:00:00+09:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := Decode(strings.NewReader(calendarOf(tt.zone, weeklyEvent(tt.tzid, true))))
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if len(events) != 1 {
+				t.Fatalf("Decode() returned %d events, want 1", len(events))
+			}
+			ev := events[0]
+			if ev.Start.TimeZone != tt.wantZone || ev.End.TimeZone != tt.wantZone {
+				t.Errorf("time zones = %q/%q, want %q", ev.Start.TimeZone, ev.End.TimeZone, tt.wantZone)
+			}
+			if ev.Start.DateTime != tt.wantTime {
+				t.Errorf("Start.DateTime = %q, want %q", ev.Start.DateTime, tt.wantTime)
+			}
+			wantExdate := "EXDATE;TZID=" + tt.wantZone + ":20240122T100000"
+			if len(ev.Recurrence) != 2 || ev.Recurrence[1] != wantExdate {
+				t.Errorf("Recurrence = %q, want the RRULE and %q", ev.Recurrence, wantExdate)
+			}
+		})
+	}
+}
+
+func TestDecodeUnmappedTimeZone(t *testing.T) {
+	zone := dstTimeZone("Custom Zone", "")
+
+	// A single event keeps its instant, converted with the VTIMEZONE
+	events, err := Decode(strings.NewReader(calendarOf(zone, weeklyEvent("Custom Zone", false))))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if got := events[0].Start; got.DateTime != "2024-01-15T09:00:00Z" || got.TimeZone != "" {
+		t.Errorf("Start = %q in %q, want 2024-01-15T09:00:00Z without a time zone", got.DateTime, got.TimeZone)
+	}
+
+	// A recurring event cannot be repeated in UTC without moving across DST changes
+	_, err = Decode(strings.NewReader(calendarOf(zone, weeklyEvent("Custom Zone", true))))
+	if err == nil || !strings.Contains(err.Error(), "Custom Zone") {
+		t.Errorf("Decode() error = %v, want an error naming the time zone", err)
+	}
+}
+
+func TestDecodeRecurringUTC(t *testing.T) {
+	ics := calendarOf("BEGIN:VEVENT\r\nUID:utc@example.com\r\nDTSTART:20240115T100000Z\r\nDTEND:20240115T110000Z\r\n" +
+		"RRULE:FREQ=DAILY;COUNT=3\r\nEND:VEVENT\r\n")
+	events, err := Decode(strings.NewReader(ics))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if got := events[0].Start.TimeZone; got != "UTC" {
+		t.Errorf("Start.TimeZone = %q, want UTC", got)
+	}
+}
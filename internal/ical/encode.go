@@ -0,0 +1,326 @@
+// Package ical converts Google Calendar events to and from iCalendar (RFC 5545) data.
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// DefaultProdID is the PRODID written by an Encoder unless changed
+const DefaultProdID = "-//longkey1//gcal//EN"
+
+// maxLineOctets is the maximum length of a content line before folding, excluding CRLF
+const maxLineOctets = 75
+
+// Encoder writes events as an iCalendar stream
+type Encoder struct {
+	w *bufio.Writer
+	// ProdID is written as the PRODID of the calendar
+	ProdID string
+	// Name is written as X-WR-CALNAME when not empty
+	Name string
+	// Now returns the time used for DTSTAMP
+	Now func() time.Time
+	// Expanded writes occurrences of recurring events, as fetched with single events, as
+	// separate events: each gets a UID of its own and no RECURRENCE-ID, since there is no
+	// series for it to override
+	Expanded bool
+}
+
+// NewEncoder returns an Encoder writing to w
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: bufio.NewWriter(w), ProdID: DefaultProdID, Now: time.Now}
+}
+
+// Encode writes a VCALENDAR containing events.
+// Recurring events keep their RRULE/EXDATE/RDATE lines; cancelled occurrences of a recurring
+// event in events are written as EXDATE of the series, and modified occurrences as VEVENTs
+// with a RECURRENCE-ID. Every time zone used by the events is written as a VTIMEZONE.
+func (e *Encoder) Encode(events []*calendar.Event) error {
+	stamp := e.Now().UTC().Format(utcLayout)
+
+	series := make(map[string]*calendar.Event)
+	for _, ev := range events {
+		if len(ev.Recurrence) > 0 {
+			series[ev.Id] = ev
+		}
+	}
+	exdates := make(map[string][]string)
+	written := make([]*calendar.Event, 0, len(events))
+	for _, ev := range events {
+		if ev.Status == "cancelled" {
+			if master, ok := series[ev.RecurringEventId]; ok && master.Start != nil && ev.OriginalStartTime != nil {
+				exdates[master.Id] = append(exdates[master.Id], dateTimeProperty("EXDATE", ev.OriginalStartTime, master.Start.TimeZone))
+			}
+			continue
+		}
+		written = append(written, ev)
+	}
+
+	e.line("BEGIN:VCALENDAR")
+	e.line("VERSION:2.0")
+	e.line("PRODID:" + e.ProdID)
+	e.line("CALSCALE:GREGORIAN")
+	if e.Name != "" {
+		e.line("X-WR-CALNAME:" + escapeText(e.Name))
+	}
+
+	for _, tz := range timeZones(written, e.Now()) {
+		e.timeZone(tz.name, tz.from, tz.to)
+	}
+
+	for _, ev := range written {
+		e.event(ev, stamp, exdates[ev.Id])
+	}
+
+	e.line("END:VCALENDAR")
+	return e.w.Flush()
+}
+
+func (e *Encoder) event(ev *calendar.Event, stamp string, exdates []string) {
+	e.line("BEGIN:VEVENT")
+	uid := ev.ICalUID
+	if uid == "" {
+		uid = ev.Id + "@google.com"
+	}
+	occurrence := ev.RecurringEventId != "" && ev.OriginalStartTime != nil
+	if occurrence && e.Expanded {
+		uid = occurrenceUID(uid, ev.OriginalStartTime)
+	}
+	e.line("UID:" + escapeText(uid))
+	e.line("DTSTAMP:" + stamp)
+	tzid := ""
+	if ev.Start != nil {
+		tzid = ev.Start.TimeZone
+		e.line(dateTimeProperty("DTSTART", ev.Start, tzid))
+	}
+	if ev.End != nil {
+		e.line(dateTimeProperty("DTEND", ev.End, tzid))
+	}
+	if occurrence && !e.Expanded {
+		e.line(dateTimeProperty("RECURRENCE-ID", ev.OriginalStartTime, tzid))
+	}
+	for _, rule := range ev.Recurrence {
+		e.line(rule)
+	}
+	for _, exdate := range exdates {
+		e.line(exdate)
+	}
+	if ev.Summary != "" {
+		e.line("SUMMARY:" + escapeText(ev.Summary))
+	}
+	if ev.Location != "" {
+		e.line("LOCATION:" + escapeText(ev.Location))
+	}
+	if ev.Description != "" {
+		e.line("DESCRIPTION:" + escapeText(ev.Description))
+	}
+	if ev.HtmlLink != "" {
+		e.line("URL:" + ev.HtmlLink)
+	}
+	if status, ok := statusValues[ev.Status]; ok {
+		e.line("STATUS:" + status)
+	}
+	if ev.Transparency == "transparent" {
+		e.line("TRANSP:TRANSPARENT")
+	} else {
+		e.line("TRANSP:OPAQUE")
+	}
+	if ev.Sequence > 0 {
+		e.line(fmt.Sprintf("SEQUENCE:%d", ev.Sequence))
+	}
+	if t, err := time.Parse(time.RFC3339, ev.Created); err == nil {
+		e.line("CREATED:" + t.UTC().Format(utcLayout))
+	}
+	if t, err := time.Parse(time.RFC3339, ev.Updated); err == nil {
+		e.line("LAST-MODIFIED:" + t.UTC().Format(utcLayout))
+	}
+	if ev.Organizer != nil && ev.Organizer.Email != "" {
+		e.line("ORGANIZER" + cnParam(ev.Organizer.DisplayName) + ":mailto:" + ev.Organizer.Email)
+	}
+	for _, a := range ev.Attendees {
+		if a.Email == "" {
+			continue
+		}
+		role := "REQ-PARTICIPANT"
+		if a.Optional {
+			role = "OPT-PARTICIPANT"
+		}
+		partstat, ok := partstatValues[a.ResponseStatus]
+		if !ok {
+			partstat = "NEEDS-ACTION"
+		}
+		e.line("ATTENDEE" + cnParam(a.DisplayName) + ";ROLE=" + role + ";PARTSTAT=" + partstat + ":mailto:" + a.Email)
+	}
+	e.line("END:VEVENT")
+}
+
+// line writes a content line, folded to maxLineOctets octets without splitting UTF-8 sequences
+func (e *Encoder) line(s string) {
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		e.w.WriteString(s[:cut])
+		e.w.WriteString("\r\n ")
+		s = s[cut:]
+		// Continuation lines start with a space, which counts towards the limit
+		limit = maxLineOctets - 1
+	}
+	e.w.WriteString(s)
+	e.w.WriteString("\r\n")
+}
+
+const (
+	utcLayout   = "20060102T150405Z"
+	localLayout = "20060102T150405"
+	dateLayout  = "20060102"
+)
+
+var statusValues = map[string]string{
+	"confirmed": "CONFIRMED",
+	"tentative": "TENTATIVE",
+	"cancelled": "CANCELLED",
+}
+
+var partstatValues = map[string]string{
+	"needsAction": "NEEDS-ACTION",
+	"accepted":    "ACCEPTED",
+	"declined":    "DECLINED",
+	"tentative":   "TENTATIVE",
+}
+
+// dateTimeProperty formats a DATE or DATE-TIME property.
+// Times are written in the time zone tzid if it is known, otherwise in UTC.
+func dateTimeProperty(name string, t *calendar.EventDateTime, tzid string) string {
+	if t.DateTime == "" {
+		d, err := time.Parse("2006-01-02", t.Date)
+		if err != nil {
+			return name + ";VALUE=DATE:" + strings.ReplaceAll(t.Date, "-", "")
+		}
+		return name + ";VALUE=DATE:" + d.Format(dateLayout)
+	}
+
+	value, err := time.Parse(time.RFC3339, t.DateTime)
+	if err != nil {
+		return name + ":" + t.DateTime
+	}
+	if t.TimeZone != "" {
+		tzid = t.TimeZone
+	}
+	if tzid != "" && tzid != "UTC" {
+		if loc, err := time.LoadLocation(tzid); err == nil {
+			return name + ";TZID=" + paramValue(tzid) + ":" + value.In(loc).Format(localLayout)
+		}
+	}
+	return name + ":" + value.UTC().Format(utcLayout)
+}
+
+// occurrenceUID returns the UID of an occurrence of the series uid exported as a separate event,
+// made unique by the original start of the occurrence
+func occurrenceUID(uid string, originalStart *calendar.EventDateTime) string {
+	start := strings.ReplaceAll(originalStart.Date, "-", "")
+	if originalStart.DateTime != "" {
+		if t, err := time.Parse(time.RFC3339, originalStart.DateTime); err == nil {
+			start = t.UTC().Format(utcLayout)
+		}
+	}
+	return uid + "-" + start
+}
+
+// textEscaper escapes TEXT values as described in RFC 5545 section 3.3.11
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// paramValue quotes a parameter value if it contains characters not allowed unquoted.
+// Double quotes cannot be represented and are dropped.
+func paramValue(s string) string {
+	s = strings.ReplaceAll(s, `"`, "")
+	s = strings.NewReplacer("\r", "", "\n", " ").Replace(s)
+	if strings.ContainsAny(s, ";:,") {
+		return `"` + s + `"`
+	}
+	return s
+}
+
+func cnParam(name string) string {
+	if name == "" {
+		return ""
+	}
+	return ";CN=" + paramValue(name)
+}
+
+// timeZoneUse is a time zone and the range of time it needs to describe
+type timeZoneUse struct {
+	name string
+	from time.Time
+	to   time.Time
+}
+
+// timeZones returns the known time zones used by events, sorted by name.
+// Recurring events are assumed to continue until a year after now.
+func timeZones(events []*calendar.Event, now time.Time) []timeZoneUse {
+	uses := make(map[string]*timeZoneUse)
+	add := func(tzid string, t time.Time) {
+		if tzid == "" || tzid == "UTC" {
+			return
+		}
+		if _, err := time.LoadLocation(tzid); err != nil {
+			return
+		}
+		u, ok := uses[tzid]
+		if !ok {
+			uses[tzid] = &timeZoneUse{name: tzid, from: t, to: t}
+			return
+		}
+		if t.Before(u.from) {
+			u.from = t
+		}
+		if t.After(u.to) {
+			u.to = t
+		}
+	}
+
+	for _, ev := range events {
+		tzid := ""
+		if ev.Start != nil {
+			tzid = ev.Start.TimeZone
+		}
+		for _, dt := range []*calendar.EventDateTime{ev.Start, ev.End, ev.OriginalStartTime} {
+			if dt == nil || dt.DateTime == "" {
+				continue
+			}
+			t, err := time.Parse(time.RFC3339, dt.DateTime)
+			if err != nil {
+				continue
+			}
+			if dt.TimeZone != "" {
+				add(dt.TimeZone, t)
+			} else {
+				add(tzid, t)
+			}
+		}
+		if len(ev.Recurrence) > 0 && ev.Start != nil && ev.Start.DateTime != "" {
+			add(tzid, now.AddDate(1, 0, 0))
+		}
+	}
+
+	result := make([]timeZoneUse, 0, len(uses))
+	for _, u := range uses {
+		result = append(result, *u)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].name < result[j].name })
+	return result
+}
@@ -0,0 +1,177 @@
+package ical
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// encodeGolden encodes events with a fixed DTSTAMP and compares the result with testdata/name.ics
+func encodeGolden(t *testing.T, name string, enc func(*Encoder), events []*calendar.Event) {
+	t.Helper()
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	e.Now = func() time.Time { return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC) }
+	if enc != nil {
+		enc(e)
+	}
+	if err := e.Encode(events); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	golden := filepath.Join("testdata", name+".ics")
+	if *update {
+		if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("Encode() differs from %s (run go test -update to rewrite it):\ngot:\n%s\nwant:\n%s", golden, buf.Bytes(), want)
+	}
+
+	for i, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+		if len(line) > maxLineOctets {
+			t.Errorf("line %d is %d octets long, want at most %d: %q", i+1, len(line), maxLineOctets, line)
+		}
+		if strings.ContainsAny(line, "\r\n") {
+			t.Errorf("line %d contains a bare CR or LF: %q", i+1, line)
+		}
+	}
+}
+
+func TestEncodeEscaping(t *testing.T) {
+	encodeGolden(t, "escaping", func(e *Encoder) { e.Name = "Team; Projects, 2024" }, []*calendar.Event{
+		{
+			Id:          "escaping",
+			Summary:     `Review; plan, and ship \ deploy`,
+			Location:    "Room 1, Building A; 3rd floor",
+			Description: "Agenda:\n1. Status\r\n2. Risks, issues; blockers\rEnd \\n literal",
+			Status:      "confirmed",
+			Start:       &calendar.EventDateTime{DateTime: "2024-01-15T10:00:00Z"},
+			End:         &calendar.EventDateTime{DateTime: "2024-01-15T11:00:00Z"},
+			Organizer:   &calendar.EventOrganizer{Email: "boss@example.com", DisplayName: `Doe, "Boss"; Jane`},
+			Attendees: []*calendar.EventAttendee{
+				{Email: "alice@example.com", DisplayName: "Alice: Lead", ResponseStatus: "accepted"},
+				{Email: "bob@example.com", DisplayName: "Bob", ResponseStatus: "tentative", Optional: true},
+				{Email: "carol@example.com", ResponseStatus: "needsAction"},
+			},
+		},
+	})
+}
+
+func TestEncodeFolding(t *testing.T) {
+	encodeGolden(t, "folding", nil, []*calendar.Event{
+		{
+			Id: "folding",
+			// 75 octets with "SUMMARY:", so it is not folded
+			Summary: strings.Repeat("a", 75-len("SUMMARY:")),
+			// Folded several times
+			Description: strings.Repeat("0123456789", 20),
+			Status:      "confirmed",
+			Start:       &calendar.EventDateTime{Date: "2024-01-15"},
+			End:         &calendar.EventDateTime{Date: "2024-01-16"},
+		},
+		{
+			Id: "folding-utf8",
+			// One octet too long, so it is folded, and multi-byte characters are never split
+			Summary:     strings.Repeat("a", 76-len("SUMMARY:")),
+			Location:    strings.Repeat("日本語", 12),
+			Description: "x" + strings.Repeat("é", 60) + strings.Repeat("🙂", 20),
+			Status:      "tentative",
+			Start:       &calendar.EventDateTime{Date: "2024-01-16"},
+			End:         &calendar.EventDateTime{Date: "2024-01-17"},
+		},
+	})
+}
+
+func TestEncodeRecurring(t *testing.T) {
+	events := []*calendar.Event{
+		{
+			Id:         "weekly",
+			ICalUID:    "weekly@google.com",
+			Summary:    "Weekly sync",
+			Status:     "confirmed",
+			Start:      &calendar.EventDateTime{DateTime: "2024-01-15T10:00:00+09:00", TimeZone: "Asia/Tokyo"},
+			End:        &calendar.EventDateTime{DateTime: "2024-01-15T10:30:00+09:00", TimeZone: "Asia/Tokyo"},
+			Recurrence: []string{"RRULE:FREQ=WEEKLY;BYDAY=MO;COUNT=4"},
+		},
+		{
+			Id:                "weekly_20240122T010000Z",
+			ICalUID:           "weekly@google.com",
+			RecurringEventId:  "weekly",
+			Summary:           "Weekly sync (moved)",
+			Status:            "confirmed",
+			Start:             &calendar.EventDateTime{DateTime: "2024-01-22T14:00:00+09:00", TimeZone: "Asia/Tokyo"},
+			End:               &calendar.EventDateTime{DateTime: "2024-01-22T14:30:00+09:00", TimeZone: "Asia/Tokyo"},
+			OriginalStartTime: &calendar.EventDateTime{DateTime: "2024-01-22T10:00:00+09:00", TimeZone: "Asia/Tokyo"},
+		},
+		{
+			Id:                "weekly_20240129T010000Z",
+			RecurringEventId:  "weekly",
+			Status:            "cancelled",
+			OriginalStartTime: &calendar.EventDateTime{DateTime: "2024-01-29T10:00:00+09:00", TimeZone: "Asia/Tokyo"},
+		},
+	}
+	encodeGolden(t, "recurring", nil, events)
+}
+
+func TestEncodeExpanded(t *testing.T) {
+	// Occurrences as listed with single events: no master, one event per occurrence
+	var events []*calendar.Event
+	for _, day := range []string{"2024-01-15", "2024-01-22"} {
+		events = append(events, &calendar.Event{
+			Id:                "weekly_" + strings.ReplaceAll(day, "-", "") + "T010000Z",
+			ICalUID:           "weekly@google.com",
+			RecurringEventId:  "weekly",
+			Summary:           "Weekly sync",
+			Status:            "confirmed",
+			Start:             &calendar.EventDateTime{DateTime: day + "T10:00:00+09:00", TimeZone: "Asia/Tokyo"},
+			End:               &calendar.EventDateTime{DateTime: day + "T10:30:00+09:00", TimeZone: "Asia/Tokyo"},
+			OriginalStartTime: &calendar.EventDateTime{DateTime: day + "T10:00:00+09:00", TimeZone: "Asia/Tokyo"},
+		})
+	}
+	events = append(events, &calendar.Event{
+		Id:                "holiday_20240116",
+		ICalUID:           "holiday@google.com",
+		RecurringEventId:  "holiday",
+		Summary:           "Day off",
+		Status:            "confirmed",
+		Start:             &calendar.EventDateTime{Date: "2024-01-16"},
+		End:               &calendar.EventDateTime{Date: "2024-01-17"},
+		OriginalStartTime: &calendar.EventDateTime{Date: "2024-01-16"},
+	})
+	encodeGolden(t, "expanded", func(e *Encoder) { e.Expanded = true }, events)
+}
+
+func TestEscapeText(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"plain", "plain"},
+		{`a\b`, `a\\b`},
+		{"a;b,c", `a\;b\,c`},
+		{"a\nb", `a\nb`},
+		{"a\r\nb", `a\nb`},
+		{"a\rb", `a\nb`},
+		{`already \n escaped`, `already \\n escaped`},
+		{"colon: stays", "colon: stays"},
+	}
+	for _, tt := range tests {
+		if got := escapeText(tt.in); got != tt.want {
+			t.Errorf("escapeText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
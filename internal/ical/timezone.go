@@ -0,0 +1,397 @@
+package ical
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// transition is a change of the UTC offset of a time zone
+type transition struct {
+	at         time.Time
+	offsetFrom int
+	offsetTo   int
+	name       string
+	dst        bool
+}
+
+// timeZone writes a VTIMEZONE for the zone name covering the years from through to.
+// The observances are listed as explicit transitions taken from the system time zone database.
+func (e *Encoder) timeZone(name string, from, to time.Time) {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return
+	}
+	start := time.Date(from.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(to.Year()+1, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	e.line("BEGIN:VTIMEZONE")
+	e.line("TZID:" + name)
+
+	// The first observance describes the offset in effect at the start of the range
+	first := start.In(loc)
+	abbr, offset := first.Zone()
+	e.writeObservance(transition{at: start, offsetFrom: offset, offsetTo: offset, name: abbr, dst: first.IsDST()})
+	for _, t := range transitions(loc, start, end) {
+		e.writeObservance(t)
+	}
+
+	e.line("END:VTIMEZONE")
+}
+
+func (e *Encoder) writeObservance(t transition) {
+	kind := "STANDARD"
+	if t.dst {
+		kind = "DAYLIGHT"
+	}
+	e.line("BEGIN:" + kind)
+	// DTSTART is the local time of the transition in the offset before it
+	e.line("DTSTART:" + t.at.In(time.FixedZone("", t.offsetFrom)).Format(localLayout))
+	e.line("TZOFFSETFROM:" + formatOffset(t.offsetFrom))
+	e.line("TZOFFSETTO:" + formatOffset(t.offsetTo))
+	if t.name != "" {
+		e.line("TZNAME:" + escapeText(t.name))
+	}
+	e.line("END:" + kind)
+}
+
+// transitions returns the offset changes of loc between start and end
+func transitions(loc *time.Location, start, end time.Time) []transition {
+	var result []transition
+	_, prev := start.In(loc).Zone()
+	for day := start; day.Before(end); day = day.Add(24 * time.Hour) {
+		next := day.Add(24 * time.Hour)
+		_, offset := next.In(loc).Zone()
+		if offset == prev {
+			continue
+		}
+		// Narrow the change down to the second
+		lo, hi := day, next
+		for hi.Sub(lo) > time.Second {
+			mid := lo.Add(hi.Sub(lo) / 2)
+			if _, o := mid.In(loc).Zone(); o == prev {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+		after := hi.In(loc)
+		abbr, _ := after.Zone()
+		result = append(result, transition{at: hi, offsetFrom: prev, offsetTo: offset, name: abbr, dst: after.IsDST()})
+		prev = offset
+	}
+	return result
+}
+
+// formatOffset formats a UTC offset in seconds as +HHMM, or +HHMMSS when it has seconds
+func formatOffset(offset int) string {
+	sign := '+'
+	if offset < 0 {
+		sign = '-'
+		offset = -offset
+	}
+	h, m, s := offset/3600, offset/60%60, offset%60
+	if s != 0 {
+		return fmt.Sprintf("%c%02d%02d%02d", sign, h, m, s)
+	}
+	return fmt.Sprintf("%c%02d%02d", sign, h, m)
+}
+
+// vtimezone is a time zone described by a VTIMEZONE component
+type vtimezone struct {
+	id          string
+	observances []*observance
+	// location is the equivalent IANA time zone, nil if none is known
+	location *time.Location
+}
+
+// observance is a STANDARD or DAYLIGHT part of a VTIMEZONE.
+// Wall clock times are stored as UTC times with the same fields.
+type observance struct {
+	start      time.Time
+	offsetFrom int
+	offsetTo   int
+	rule       map[string]string
+	rdates     []time.Time
+}
+
+func parseTimeZone(c *component) (*vtimezone, error) {
+	z := &vtimezone{id: c.value("TZID")}
+	if z.id == "" {
+		return nil, fmt.Errorf("line %d: VTIMEZONE has no TZID", c.line)
+	}
+	for _, sub := range c.components {
+		if sub.name != "STANDARD" && sub.name != "DAYLIGHT" {
+			continue
+		}
+		o := &observance{}
+		var err error
+		if o.start, err = time.Parse(localLayout, sub.value("DTSTART")); err != nil {
+			return nil, fmt.Errorf("line %d: invalid DTSTART in VTIMEZONE %s", sub.line, z.id)
+		}
+		if o.offsetFrom, err = parseOffset(sub.value("TZOFFSETFROM")); err != nil {
+			return nil, fmt.Errorf("line %d: %w", sub.line, err)
+		}
+		if o.offsetTo, err = parseOffset(sub.value("TZOFFSETTO")); err != nil {
+			return nil, fmt.Errorf("line %d: %w", sub.line, err)
+		}
+		if rrule := sub.value("RRULE"); rrule != "" {
+			o.rule = make(map[string]string)
+			for _, part := range strings.Split(rrule, ";") {
+				k, v, _ := strings.Cut(part, "=")
+				o.rule[strings.ToUpper(k)] = strings.ToUpper(v)
+			}
+		}
+		for _, p := range sub.props {
+			if p.name != "RDATE" {
+				continue
+			}
+			for _, v := range strings.Split(p.value, ",") {
+				if t, err := time.Parse(localLayout, v); err == nil {
+					o.rdates = append(o.rdates, t)
+				}
+			}
+		}
+		z.observances = append(z.observances, o)
+	}
+	if len(z.observances) == 0 {
+		return nil, fmt.Errorf("line %d: VTIMEZONE %s has no STANDARD or DAYLIGHT", c.line, z.id)
+	}
+	z.location = z.ianaLocation(c.value("X-LIC-LOCATION"))
+	return z, nil
+}
+
+// ianaLocation finds the IANA time zone a VTIMEZONE stands for: the X-LIC-LOCATION given by
+// some producers, the zone of a Windows time zone name, an IANA name at the end of a TZID such
+// as /mozilla.org/20050126_1/Europe/Berlin, or Etc/GMT±N for a whole-hour zone without DST
+func (z *vtimezone) ianaLocation(licLocation string) *time.Location {
+	candidates := []string{licLocation, windowsZones[z.id]}
+	parts := strings.Split(strings.Trim(z.id, "/"), "/")
+	for n := 3; n >= 2; n-- {
+		if len(parts) >= n {
+			candidates = append(candidates, strings.Join(parts[len(parts)-n:], "/"))
+		}
+	}
+	for _, name := range candidates {
+		// Only area/location names, since LoadLocation also accepts "Local"
+		if !strings.Contains(name, "/") {
+			continue
+		}
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc
+		}
+	}
+
+	offset := z.observances[0].offsetTo
+	for _, o := range z.observances {
+		if o.offsetFrom != offset || o.offsetTo != offset {
+			return nil
+		}
+	}
+	if offset%3600 != 0 {
+		return nil
+	}
+	// The sign of Etc/GMT zones is inverted: Etc/GMT-9 is UTC+9
+	name := "Etc/UTC"
+	if offset != 0 {
+		name = fmt.Sprintf("Etc/GMT%+d", -offset/3600)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil
+	}
+	return loc
+}
+
+// windowsZones maps the Windows time zone names used by Outlook and Exchange to IANA time zones,
+// following the CLDR mapping for the territory 001
+var windowsZones = map[string]string{
+	"Dateline Standard Time":          "Etc/GMT+12",
+	"Hawaiian Standard Time":          "Pacific/Honolulu",
+	"Alaskan Standard Time":           "America/Anchorage",
+	"Pacific Standard Time":           "America/Los_Angeles",
+	"US Mountain Standard Time":       "America/Phoenix",
+	"Mountain Standard Time":          "America/Denver",
+	"Central Standard Time":           "America/Chicago",
+	"Central Standard Time (Mexico)":  "America/Mexico_City",
+	"Canada Central Standard Time":    "America/Regina",
+	"Central America Standard Time":   "America/Guatemala",
+	"Eastern Standard Time":           "America/New_York",
+	"US Eastern Standard Time":        "America/Indiana/Indianapolis",
+	"SA Pacific Standard Time":        "America/Bogota",
+	"Atlantic Standard Time":          "America/Halifax",
+	"Pacific SA Standard Time":        "America/Santiago",
+	"Newfoundland Standard Time":      "America/St_Johns",
+	"E. South America Standard Time":  "America/Sao_Paulo",
+	"Argentina Standard Time":         "America/Argentina/Buenos_Aires",
+	"UTC":                             "Etc/UTC",
+	"GMT Standard Time":               "Europe/London",
+	"Greenwich Standard Time":         "Atlantic/Reykjavik",
+	"W. Europe Standard Time":         "Europe/Berlin",
+	"Central Europe Standard Time":    "Europe/Budapest",
+	"Central European Standard Time":  "Europe/Warsaw",
+	"Romance Standard Time":           "Europe/Paris",
+	"W. Central Africa Standard Time": "Africa/Lagos",
+	"GTB Standard Time":               "Europe/Bucharest",
+	"FLE Standard Time":               "Europe/Kiev",
+	"E. Europe Standard Time":         "Europe/Chisinau",
+	"Egypt Standard Time":             "Africa/Cairo",
+	"South Africa Standard Time":      "Africa/Johannesburg",
+	"Israel Standard Time":            "Asia/Jerusalem",
+	"Turkey Standard Time":            "Europe/Istanbul",
+	"Russian Standard Time":           "Europe/Moscow",
+	"Arab Standard Time":              "Asia/Riyadh",
+	"Iran Standard Time":              "Asia/Tehran",
+	"Arabian Standard Time":           "Asia/Dubai",
+	"Pakistan Standard Time":          "Asia/Karachi",
+	"India Standard Time":             "Asia/Kolkata",
+	"Nepal Standard Time":             "Asia/Kathmandu",
+	"Bangladesh Standard Time":        "Asia/Dhaka",
+	"SE Asia Standard Time":           "Asia/Bangkok",
+	"China Standard Time":             "Asia/Shanghai",
+	"Singapore Standard Time":         "Asia/Singapore",
+	"Taipei Standard Time":            "Asia/Taipei",
+	"W. Australia Standard Time":      "Australia/Perth",
+	"Tokyo Standard Time":             "Asia/Tokyo",
+	"Korea Standard Time":             "Asia/Seoul",
+	"Cen. Australia Standard Time":    "Australia/Adelaide",
+	"AUS Central Standard Time":       "Australia/Darwin",
+	"E. Australia Standard Time":      "Australia/Brisbane",
+	"AUS Eastern Standard Time":       "Australia/Sydney",
+	"Tasmania Standard Time":          "Australia/Hobart",
+	"New Zealand Standard Time":       "Pacific/Auckland",
+}
+
+// offset returns the UTC offset in seconds in effect at a wall clock time
+func (z *vtimezone) offset(wall time.Time) int {
+	var latest time.Time
+	var current *observance
+	for _, o := range z.observances {
+		if t, ok := o.lastOnset(wall); ok && (current == nil || t.After(latest)) {
+			latest, current = t, o
+		}
+	}
+	if current != nil {
+		return current.offsetTo
+	}
+
+	// Before the first observance the offset it changes from applies
+	first := z.observances[0]
+	for _, o := range z.observances[1:] {
+		if o.start.Before(first.start) {
+			first = o
+		}
+	}
+	return first.offsetFrom
+}
+
+// lastOnset returns the latest time the observance started at or before wall
+func (o *observance) lastOnset(wall time.Time) (time.Time, bool) {
+	var latest time.Time
+	found := false
+	consider := func(t time.Time) {
+		if !t.IsZero() && !t.Before(o.start) && !t.After(wall) && (!found || t.After(latest)) {
+			latest, found = t, true
+		}
+	}
+
+	consider(o.start)
+	for _, t := range o.rdates {
+		consider(t)
+	}
+	if o.rule != nil && o.rule["FREQ"] == "YEARLY" {
+		var until time.Time
+		if v := o.rule["UNTIL"]; v != "" {
+			until, _ = time.Parse(utcLayout, v)
+			if until.IsZero() {
+				until, _ = time.Parse(localLayout, v)
+			}
+		}
+		for _, year := range []int{wall.Year() - 1, wall.Year()} {
+			t := o.yearlyOnset(year)
+			if !until.IsZero() && t.After(until) {
+				continue
+			}
+			consider(t)
+		}
+	}
+	return latest, found
+}
+
+// yearlyOnset returns the onset of a yearly rule such as BYMONTH=3;BYDAY=2SU in year,
+// or the zero time if the rule is not understood
+func (o *observance) yearlyOnset(year int) time.Time {
+	month := int(o.start.Month())
+	if v := o.rule["BYMONTH"]; v != "" {
+		if _, err := fmt.Sscanf(v, "%d", &month); err != nil {
+			return time.Time{}
+		}
+	}
+	at := func(day int) time.Time {
+		return time.Date(year, time.Month(month), day, o.start.Hour(), o.start.Minute(), o.start.Second(), 0, time.UTC)
+	}
+
+	byday := o.rule["BYDAY"]
+	if byday == "" {
+		return at(o.start.Day())
+	}
+	n := 0
+	if len(byday) > 2 {
+		if _, err := fmt.Sscanf(byday[:len(byday)-2], "%d", &n); err != nil {
+			return time.Time{}
+		}
+	}
+	weekday, ok := weekdayNames[byday[len(byday)-2:]]
+	if !ok {
+		return time.Time{}
+	}
+
+	daysInMonth := at(1).AddDate(0, 1, -1).Day()
+	var days []int
+	for day := 1; day <= daysInMonth; day++ {
+		if at(day).Weekday() == weekday {
+			days = append(days, day)
+		}
+	}
+	switch {
+	case n > 0 && n <= len(days):
+		return at(days[n-1])
+	case n < 0 && -n <= len(days):
+		return at(days[len(days)+n])
+	case n == 0:
+		// e.g. BYMONTHDAY=8,9,10,11,12,13,14;BYDAY=SU
+		for _, v := range strings.Split(o.rule["BYMONTHDAY"], ",") {
+			var md int
+			if _, err := fmt.Sscanf(v, "%d", &md); err == nil && md >= 1 && md <= daysInMonth && at(md).Weekday() == weekday {
+				return at(md)
+			}
+		}
+	}
+	return time.Time{}
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// parseOffset parses a UTC offset of the form +HHMM or +HHMMSS into seconds
+func parseOffset(s string) (int, error) {
+	if (len(s) != 5 && len(s) != 7) || (s[0] != '+' && s[0] != '-') {
+		return 0, fmt.Errorf("invalid UTC offset %q", s)
+	}
+	var h, m, sec int
+	if _, err := fmt.Sscanf(s[1:5], "%02d%02d", &h, &m); err != nil {
+		return 0, fmt.Errorf("invalid UTC offset %q", s)
+	}
+	if len(s) == 7 {
+		if _, err := fmt.Sscanf(s[5:], "%02d", &sec); err != nil {
+			return 0, fmt.Errorf("invalid UTC offset %q", s)
+		}
+	}
+	offset := h*3600 + m*60 + sec
+	if s[0] == '-' {
+		offset = -offset
+	}
+	return offset, nil
+}